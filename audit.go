@@ -0,0 +1,109 @@
+package osm
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// WithContext 返回使用ctx的Osm，执行sql和开启事务时使用ctx(取消或超时时中止)，
+// InsertStruct等方法还通过Options.ActorFromContext从ctx中取得autoActor的值。返回的Osm与o共用连接，不能Close。
+//
+// 代码
+//
+//	type Article struct {
+//		ID        int64     `db:"id,pk,auto"`
+//		CreatedAt time.Time `db:"created_at,autoCreateTime"`
+//		UpdatedAt time.Time `db:"updated_at,autoUpdateTime"`
+//		CreatedBy string    `db:"created_by,autoActor"`
+//	}
+//
+//	_, _, err := o.WithContext(r.Context()).InsertStruct("", article)
+func (o *Osm) WithContext(ctx context.Context) *Osm {
+	withCtx := *o
+	withCtx.callCtx = ctx
+	withCtx.derived = true
+	return &withCtx
+}
+
+// WithContext 返回使用ctx的Tx，与Osm.WithContext()相同
+func (o *Tx) WithContext(ctx context.Context) *Tx {
+	withCtx := *o
+	withCtx.callCtx = ctx
+	return &withCtx
+}
+
+// context WithContext()设置的context，未设置时为context.Background()
+func (o *osmBase) context() context.Context {
+	if o.callCtx == nil {
+		return context.Background()
+	}
+	return o.callCtx
+}
+
+// now 当前时间，Options.Clock为空时使用time.Now
+func (options *Options) now() time.Time {
+	if options.Clock != nil {
+		return options.Clock()
+	}
+	return time.Now()
+}
+
+// timeValue 按成员的类型取得写入的时间，整数类型的成员为Unix时间戳
+func timeValue(field *structFieldInfo, now time.Time) interface{} {
+	t := *field.t
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return now.Unix()
+	}
+	return now
+}
+
+// setField 将value写入elem的成员，类型相同时直接写入，否则按读取的规则转换
+func (o *osmBase) setField(logPrefix string, elem reflect.Value, field *structFieldInfo, value interface{}) error {
+	destType := *field.t
+	if field.isPtr {
+		destType = destType.Elem()
+	}
+	if reflect.TypeOf(value) == destType {
+		setValue(field.isPtr, structFieldAlloc(elem, field), value, destType)
+		return nil
+	}
+	return o.convertAssign(logPrefix, structFieldAlloc(elem, field), value, field.isPtr, destType)
+}
+
+// fillAudit 写入前填入autoCreateTime、autoUpdateTime和autoActor的成员，insert为false时只填入autoUpdateTime
+//
+// elem不可修改(obj不是指针)时在副本中填入，返回用于绑定参数的struct。
+func (o *osmBase) fillAudit(logPrefix string, t *crudTable, elem reflect.Value, insert bool) (reflect.Value, error) {
+	if !t.audited {
+		return elem, nil
+	}
+	elem = addressable(elem)
+	now := o.options.now()
+	var actor interface{}
+	if insert && o.options.ActorFromContext != nil {
+		actor = o.options.ActorFromContext(o.context())
+	}
+	for _, c := range t.columns {
+		f := c.field
+		var value interface{}
+		switch {
+		case f.updateTime && !insert:
+			value = timeValue(f, now)
+		case (f.createTime || f.updateTime) && insert && isZeroField(elem, f):
+			value = timeValue(f, now)
+		case f.actor && actor != nil && isZeroField(elem, f):
+			value = actor
+		default:
+			continue
+		}
+		if err := o.setField(logPrefix, elem, f, value); err != nil {
+			return elem, err
+		}
+	}
+	return elem, nil
+}
@@ -0,0 +1,119 @@
+package osm
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testActorKey struct{}
+
+type testArticle struct {
+	ID        int64      `db:"id,pk,auto"`
+	Title     string     `db:"title"`
+	CreatedAt time.Time  `db:"created_at,autoCreateTime"`
+	UpdatedAt *time.Time `db:"updated_at,autoUpdateTime"`
+	CreatedBy string     `db:"created_by,autoActor"`
+}
+
+func (testArticle) TableName() string { return "article" }
+
+func newAuditOsm(t *testing.T) (*Osm, sqlmock.Sqlmock, time.Time) {
+	base, mock := newMockOsm(t)
+	now := time.Date(2024, 6, 15, 10, 30, 0, 0, time.UTC)
+	base.options.Clock = func() time.Time { return now }
+	base.options.ActorFromContext = func(ctx context.Context) interface{} {
+		return ctx.Value(testActorKey{})
+	}
+	base.options.BindTimeAs = BindTimeNative
+	return &Osm{osmBase: *base}, mock, now
+}
+
+func TestAuditInsert(t *testing.T) {
+	o, mock, now := newAuditOsm(t)
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `article` (`title`, `created_at`, `updated_at`, `created_by`) VALUES (?, ?, ?, ?)")).
+		ExpectExec().WithArgs("hello", now, now, "alice").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	ctx := context.WithValue(context.Background(), testActorKey{}, "alice")
+	article := &testArticle{Title: "hello"}
+	if _, _, err := o.WithContext(ctx).InsertStruct("", article); err != nil {
+		t.Fatal(err)
+	}
+	if !article.CreatedAt.Equal(now) || article.UpdatedAt == nil || !article.UpdatedAt.Equal(now) || article.CreatedBy != "alice" {
+		t.Errorf("audit fields not filled: %+v", article)
+	}
+	if o.callCtx != nil {
+		t.Error("WithContext should not modify the original Osm")
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	o, _, _ := newAuditOsm(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var id int64
+	if _, err := o.WithContext(ctx).Select("SELECT id FROM article").Value(&id); err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("query err = %v, want context.Canceled", err)
+	}
+	if _, err := o.WithContext(ctx).Update("UPDATE article SET title = 'x'"); !errors.Is(err, context.Canceled) {
+		t.Errorf("exec err = %v, want context.Canceled", err)
+	}
+
+	if err := o.WithContext(context.Background()).Close(); err == nil {
+		t.Error("expected error closing WithContext() Osm")
+	}
+	if o.db == nil {
+		t.Error("closing WithContext() Osm should not affect the original")
+	}
+}
+
+func TestAuditInsertKeepsValues(t *testing.T) {
+	o, mock, now := newAuditOsm(t)
+	created := now.Add(-time.Hour)
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `article` (`title`, `created_at`, `updated_at`, `created_by`) VALUES (?, ?, ?, ?)")).
+		ExpectExec().WithArgs("hello", created, now, "bob").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// 非指针参数在副本中填入，已有的值不覆盖，没有context时不填入actor
+	if _, _, err := o.InsertStruct("", testArticle{Title: "hello", CreatedAt: created, CreatedBy: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuditUpdate(t *testing.T) {
+	o, mock, now := newAuditOsm(t)
+	mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `article` SET `title` = ?, `updated_at` = ? WHERE `id` = ?")).
+		ExpectExec().WithArgs("new", now, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `article` SET `title` = ?, `updated_at` = ? WHERE `id` = ?")).
+		ExpectExec().WithArgs("new", now, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	article := &testArticle{ID: 1, Title: "new", CreatedBy: "alice"}
+	if _, err := o.UpdateStruct("", article); err != nil {
+		t.Fatal(err)
+	}
+	if article.UpdatedAt == nil || !article.UpdatedAt.Equal(now) || !article.CreatedAt.IsZero() {
+		t.Errorf("audit fields: %+v", article)
+	}
+	if _, err := o.UpdateStruct("", article, "title"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuditUpsert(t *testing.T) {
+	o, mock, now := newAuditOsm(t)
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `article` (`id`, `title`, `created_at`, `updated_at`, `created_by`) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE `title` = VALUES(`title`), `updated_at` = VALUES(`updated_at`)")).
+		ExpectExec().WithArgs(int64(1), "a", now, now, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := o.Upsert("", []string{"id"}, []string{"title"}, testArticle{ID: 1, Title: "a"}); err != nil {
+		t.Fatal(err)
+	}
+}
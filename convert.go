@@ -7,14 +7,16 @@
 package osm
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 )
 
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
 func setValue(isPtr bool, dest reflect.Value, value interface{}, destType reflect.Type) {
 	if isPtr {
 		data := reflect.New(destType)
@@ -39,6 +41,33 @@ func setValueConvert(isPtr bool, dest reflect.Value, value interface{}, destType
 // An error is returned if the copy would result in loss of information.
 // dest should be a pointer type.
 func (o *osmBase) convertAssign(logPrefix string, dest reflect.Value, src interface{}, destIsPtr bool, destType reflect.Type) error {
+	if c := o.findConverter(destType); c != nil && c.scan != nil {
+		value, err := c.scan(src)
+		if err != nil {
+			return err
+		}
+		return scanConverted(dest, value, destIsPtr, destType)
+	}
+	if reflect.PointerTo(destType).Implements(nullScannerType) {
+		return o.scanNullValue(logPrefix, dest, src, destIsPtr, destType)
+	}
+	if reflect.PointerTo(destType).Implements(scannerType) && (src != nil || !destIsPtr) {
+		data := reflect.New(destType)
+		if err := data.Interface().(sql.Scanner).Scan(src); err != nil {
+			return err
+		}
+		setValue(destIsPtr, dest, data.Elem().Interface(), destType)
+		return nil
+	}
+	if isTextDest(destType) {
+		switch s := src.(type) {
+		case string:
+			return o.scanText(logPrefix, dest, []byte(s), destIsPtr, destType)
+		case []byte:
+			return o.scanText(logPrefix, dest, s, destIsPtr, destType)
+		}
+	}
+
 	switch s := src.(type) {
 	case string:
 		switch destType.Kind() {
@@ -47,12 +76,19 @@ func (o *osmBase) convertAssign(logPrefix string, dest reflect.Value, src interf
 				setValue(destIsPtr, dest, []byte(s), destType)
 				return nil
 			}
+			if isPGArrayDest(destType) {
+				return scanPGArray(dest, s, destIsPtr, destType)
+			}
 		}
 	case []byte:
 		switch destType.Kind() {
 		case reflect.String:
 			setValue(destIsPtr, dest, string(s), destType)
 			return nil
+		case reflect.Slice:
+			if isPGArrayDest(destType) {
+				return scanPGArray(dest, string(s), destIsPtr, destType)
+			}
 		}
 	case time.Time:
 		switch destType.Kind() {
@@ -65,14 +101,21 @@ func (o *osmBase) convertAssign(logPrefix string, dest reflect.Value, src interf
 				return nil
 			}
 		}
-		src = s.Local()
+		src = s.In(o.options.timeLocation())
 	case nil:
 		if destIsPtr {
-		dest.Set(reflect.New(destType.Elem()))
-	} else {
-			dest.Set(reflect.New(destType).Elem())
+			// 指针成员读取NULL时为nil，以区分NULL和零值
+			dest.Set(reflect.Zero(dest.Type()))
+			return nil
 		}
-		return nil
+		return o.assignNull(dest, destType)
+	}
+
+	if isNumberKind(destType.Kind()) {
+		return o.convertNumber(logPrefix, dest, src, destIsPtr, destType)
+	}
+	if isBigNumberType(destType) {
+		return o.convertBigNumber(logPrefix, dest, src, destIsPtr, destType)
 	}
 
 	srcType := reflect.TypeOf(src)
@@ -115,35 +158,6 @@ func (o *osmBase) convertAssign(logPrefix string, dest reflect.Value, src interf
 		}
 		setValue(destIsPtr, dest, bv.(bool), destType)
 		return nil
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		s := asString(src)
-		s = trimZeroDecimal(s)
-		i64, err := strconv.ParseInt(s, 10, destType.Bits())
-		if err != nil {
-			o.options.WarnLogger.Log(logPrefix+"convertAssign Int error", map[string]string{"error": err.Error()})
-			return err
-		}
-		dest.SetInt(i64)
-		return nil
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		s := asString(src)
-		s = trimZeroDecimal(s)
-		u64, err := strconv.ParseUint(s, 10, destType.Bits())
-		if err != nil {
-			o.options.WarnLogger.Log(logPrefix+"convertAssign Uint error", map[string]string{"error": err.Error()})
-			return err
-		}
-		dest.SetUint(u64)
-		return nil
-	case reflect.Float32, reflect.Float64:
-		s := asString(src)
-		f64, err := strconv.ParseFloat(s, destType.Bits())
-		if err != nil {
-			o.options.WarnLogger.Log(logPrefix+"convertAssign Float error", map[string]string{"error": err.Error()})
-			return err
-		}
-		dest.SetFloat(f64)
-		return nil
 	case reflect.Struct:
 		if destType.String() == "time.Time" {
 			str := ""
@@ -152,25 +166,15 @@ func (o *osmBase) convertAssign(logPrefix string, dest reflect.Value, src interf
 				str = s
 			case []byte:
 				str = string(s)
+			case int64:
+				setValue(destIsPtr, dest, time.Unix(s, 0).In(o.options.timeLocation()), destType)
+				return nil
 			case nil:
 				return nil
 			}
 			if str != "" {
-				var t time.Time
-				var err error
-				if len(str) >= 19 {
-					if strings.Contains(str, "T") {
-						t, err = time.Parse(time.RFC3339Nano, str)
-					} else {
-						str = str[:19]
-						t, err = time.ParseInLocation(formatDateTime, str, time.Local)
-					}
-				} else if len(str) >= 10 {
-					str = str[:10]
-					t, err = time.ParseInLocation(formatDate, str, time.Local)
-				}
+				t, err := o.options.parseTime(str)
 				if err == nil {
-					t = t.Local()
 					setValue(destIsPtr, dest, t, destType)
 				} else {
 					o.options.WarnLogger.Log(logPrefix+"convertAssign Time error", map[string]string{"error": err.Error()})
@@ -225,20 +229,3 @@ func asBytes(buf []byte, rv reflect.Value) (b []byte, ok bool) {
 	}
 	return
 }
-
-func trimZeroDecimal(s string) string {
-	var foundZero bool
-	for i := len(s); i > 0; i-- {
-		switch s[i-1] {
-		case '.':
-			if foundZero {
-				return s[:i-1]
-			}
-		case '0':
-			foundZero = true
-		default:
-			return s
-		}
-	}
-	return s
-}
@@ -38,29 +38,6 @@ func TestAsString(t *testing.T) {
 	}
 }
 
-func TestTrimZeroDecimal(t *testing.T) {
-	tests := []struct {
-		input string
-		want  string
-	}{
-		{"123.00", "123"},
-		{"123.0", "123"},
-		{"123.10", "123.10"},
-		{"123.456", "123.456"},
-		{"123", "123"},
-		{"0.00", "0"},
-		{"", ""},
-		{".00", ""},
-	}
-
-	for _, tc := range tests {
-		got := trimZeroDecimal(tc.input)
-		if got != tc.want {
-			t.Errorf("trimZeroDecimal(%q) = %q, want %q", tc.input, got, tc.want)
-		}
-	}
-}
-
 func TestSetValue(t *testing.T) {
 	strType := reflect.TypeOf("")
 	intType := reflect.TypeOf(0)
@@ -192,11 +169,8 @@ func TestConvertAssign(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		if dest.IsNil() {
-			t.Error("expected non-nil pointer after nil conversion")
-		}
-		if dest.Elem().String() != "" {
-			t.Errorf("expected empty string, got %q", dest.Elem().String())
+		if !dest.IsNil() {
+			t.Errorf("expected nil pointer after nil conversion, got %q", dest.Elem().String())
 		}
 	})
 
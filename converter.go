@@ -0,0 +1,128 @@
+package osm
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ScanFunc 将数据库返回的值转为注册类型的值
+//
+// src可能为nil、int64、float64、bool、[]byte、string、time.Time，返回nil表示零值。
+type ScanFunc func(src interface{}) (interface{}, error)
+
+// BindFunc 将注册类型的参数转为driver支持的值，如string、int64、[]byte
+type BindFunc func(v interface{}) (interface{}, error)
+
+type converter struct {
+	scan ScanFunc
+	bind BindFunc
+}
+
+type converterRegistry struct {
+	mu         sync.RWMutex
+	converters map[reflect.Type]*converter
+}
+
+func (r *converterRegistry) register(t reflect.Type, scan ScanFunc, bind BindFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.converters == nil {
+		r.converters = map[reflect.Type]*converter{}
+	}
+	r.converters[t] = &converter{scan: scan, bind: bind}
+}
+
+func (r *converterRegistry) get(t reflect.Type) *converter {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.converters[t]
+}
+
+// globalConverters 通过RegisterConverter注册的转换器，所有Osm共用
+var globalConverters = &converterRegistry{}
+
+// RegisterConverter 注册类型t的转换器，读取结果和绑定参数时优先于内置的转换规则
+//
+// scan为nil时读取使用内置规则，bind为nil时绑定使用内置规则。
+// 注册的是非指针类型，*T的成员和参数也会使用该转换器。
+//
+// 如：
+//
+//	osm.RegisterConverter(reflect.TypeOf(decimal.Decimal{}),
+//		func(src interface{}) (interface{}, error) {
+//			if src == nil {
+//				return nil, nil
+//			}
+//			return decimal.NewFromString(fmt.Sprintf("%s", src))
+//		},
+//		func(v interface{}) (interface{}, error) {
+//			return v.(decimal.Decimal).String(), nil
+//		})
+func RegisterConverter(t reflect.Type, scan ScanFunc, bind BindFunc) {
+	globalConverters.register(t, scan, bind)
+}
+
+// RegisterConverter 注册只对当前Osm(及其事务)生效的转换器，优先于全局注册的转换器
+func (o *Osm) RegisterConverter(t reflect.Type, scan ScanFunc, bind BindFunc) {
+	o.options.converters.register(t, scan, bind)
+}
+
+// findConverter 按当前Osm、全局的顺序查找类型t的转换器
+func (o *osmBase) findConverter(t reflect.Type) *converter {
+	if c := o.options.converters.get(t); c != nil {
+		return c
+	}
+	return globalConverters.get(t)
+}
+
+// scanConverted 将ScanFunc返回的值存入dest
+func scanConverted(dest reflect.Value, value interface{}, destIsPtr bool, destType reflect.Type) error {
+	if value == nil {
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Type() == reflect.PointerTo(destType) {
+		if v.IsNil() {
+			dest.Set(reflect.Zero(dest.Type()))
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.Type().AssignableTo(destType) {
+		return fmt.Errorf("converter of %s returned %T", destType, value)
+	}
+	if destIsPtr {
+		data := reflect.New(destType)
+		data.Elem().Set(v)
+		dest.Set(data)
+	} else {
+		dest.Set(v)
+	}
+	return nil
+}
+
+// bindConverted 使用注册的转换器转换参数，没有转换器时ok为false
+func (o *osmBase) bindConverted(v reflect.Value) (value interface{}, ok bool, err error) {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if c := o.findConverter(v.Type()); c != nil && c.bind != nil {
+		value, err = c.bind(v.Interface())
+		return value, true, err
+	}
+	if v.Kind() == reflect.Ptr {
+		if c := o.findConverter(v.Type().Elem()); c != nil && c.bind != nil {
+			if v.IsNil() {
+				return nil, true, nil
+			}
+			value, err = c.bind(v.Elem().Interface())
+			return value, true, err
+		}
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,120 @@
+package osm
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testCents int64
+
+// registerTestCents 注册testCents的全局转换器，测试结束后移除
+func registerTestCents(t *testing.T) {
+	typ := reflect.TypeOf(testCents(0))
+	RegisterConverter(typ,
+		func(src interface{}) (interface{}, error) {
+			if src == nil {
+				return nil, nil
+			}
+			f, ok := src.(float64)
+			if !ok {
+				return nil, errors.New("cents: unexpected source")
+			}
+			return testCents(f*100 + 0.5), nil
+		},
+		func(v interface{}) (interface{}, error) {
+			return float64(v.(testCents)) / 100, nil
+		})
+	t.Cleanup(func() {
+		globalConverters.mu.Lock()
+		defer globalConverters.mu.Unlock()
+		delete(globalConverters.converters, typ)
+	})
+}
+
+func TestGlobalConverter(t *testing.T) {
+	registerTestCents(t)
+	type product struct {
+		ID    int64      `db:"id"`
+		Price testCents  `db:"price"`
+		Old   *testCents `db:"old"`
+	}
+
+	o, mock := newMockOsm(t)
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "price", "old"}).AddRow(1, 12.34, nil))
+
+	var p product
+	if _, err := o.Select("SELECT id, price, old FROM product").Struct(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Price != 1234 || p.Old != nil {
+		t.Errorf("got %+v", p)
+	}
+
+	_, params, err := o.readSQLParamsBySQL("test", "UPDATE product SET price = #{price} WHERE id IN #{ids}",
+		map[string]interface{}{"price": testCents(250), "ids": []testCents{100, 200}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(params, []interface{}{2.5, 1.0, 2.0}) {
+		t.Errorf("got %#v", params)
+	}
+	_, params, err = o.readSQLParamsBySQL("test", "UPDATE product SET price = #{price}, old = #{old} WHERE id = #{id}",
+		product{ID: 1, Price: 250})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(params, []interface{}{2.5, nil, int64(1)}) {
+		t.Errorf("got %#v", params)
+	}
+}
+
+func TestOsmConverter(t *testing.T) {
+	type host struct {
+		IP net.IP `db:"ip"`
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{}
+	opts.tidy()
+	o := &Osm{osmBase: osmBase{db: db, dbType: dbTypeMysql, options: &opts}}
+	o.RegisterConverter(reflect.TypeOf(net.IP{}),
+		func(src interface{}) (interface{}, error) {
+			ip := net.ParseIP(string(src.([]byte)))
+			if ip == nil {
+				return nil, errors.New("invalid ip")
+			}
+			return ip, nil
+		},
+		func(v interface{}) (interface{}, error) {
+			return v.(net.IP).String(), nil
+		})
+
+	mock.ExpectQuery("SELECT").WithArgs("10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"ip"}).AddRow([]byte("10.0.0.1")))
+
+	var h host
+	if _, err := o.Select("SELECT ip FROM host WHERE ip = #{ip}", host{IP: net.ParseIP("10.0.0.1")}).Struct(&h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.IP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("got %v", h.IP)
+	}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"ip"}).AddRow([]byte("bad")))
+	if _, err := o.Select("SELECT ip FROM host").Struct(&h); err == nil {
+		t.Fatal("expected scan error")
+	}
+
+	other, _ := newMockOsm(t)
+	if other.findConverter(reflect.TypeOf(net.IP{})) != nil {
+		t.Error("converter registered on one Osm should not leak to another")
+	}
+}
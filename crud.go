@@ -0,0 +1,495 @@
+package osm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TableNamer 实现了TableName的struct，InsertStruct等方法的table参数为空时使用TableName()作为表名
+type TableNamer interface {
+	TableName() string
+}
+
+// crudColumn struct成员对应的列
+type crudColumn struct {
+	name  string // 列名，未加引号
+	param string // 生成的sql中绑定该成员的参数名，按列的顺序生成，如#{c0}中的c0
+	field *structFieldInfo
+}
+
+// crudTable 根据struct的db标签生成sql所需的信息
+type crudTable struct {
+	table   string        // 表名，已加引号
+	columns []*crudColumn // 全部列，按成员定义的顺序
+	pks     []*crudColumn // pk选项的列
+	auto    *crudColumn   // auto选项的列，如自增主键
+	version *crudColumn   // version选项的列，乐观锁的版本号
+	deleted *crudColumn   // softdelete选项的列，软删除的时间
+	audited bool          // 是否有autoCreateTime、autoUpdateTime或autoActor选项的列
+}
+
+// crudTableOf 取得structType对应的表和列，table为空时使用TableName()
+func (o *osmBase) crudTableOf(table string, structType reflect.Type) (*crudTable, error) {
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("参数应为struct或struct的指针，而您传入的是%s", structType)
+	}
+	if table == "" {
+		if namer, ok := reflect.New(structType).Interface().(TableNamer); ok {
+			table = namer.TableName()
+		}
+	}
+	if table == "" {
+		return nil, fmt.Errorf("%s没有指定表名，请传入table参数或实现TableName()方法", structType)
+	}
+
+	tagMap := map[string]*structFieldInfo{}
+	nameMap := map[string]*structFieldInfo{}
+	if err := getStructFieldMap(structType, tagMap, nameMap); err != nil {
+		return nil, err
+	}
+	fields := make([]*structFieldInfo, 0, len(nameMap))
+	for _, field := range nameMap {
+		// 同层同名的成员无法访问，嵌套struct的成员不对应本表的列
+		if field.ambiguous || field.prefix != "" {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	t := &crudTable{table: o.quoteIdent(table)}
+	mapper := o.options.nameMapper()
+	for i, field := range fields {
+		c := &crudColumn{name: field.column, param: "c" + strconv.Itoa(i), field: field}
+		if c.name == "" {
+			c.name = mapper.FieldToColumn(field.n)
+		}
+		t.columns = append(t.columns, c)
+		if field.pk {
+			t.pks = append(t.pks, c)
+		}
+		if field.auto && t.auto == nil {
+			t.auto = c
+		}
+		if field.version && t.version == nil {
+			t.version = c
+		}
+		if field.softDelete && t.deleted == nil {
+			t.deleted = c
+		}
+		if field.createTime || field.updateTime || field.actor {
+			t.audited = true
+		}
+	}
+	return t, nil
+}
+
+// structOf 取得obj中的struct，obj可以是struct或struct的指针
+func structOf(obj interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("参数为nil")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("参数应为struct或struct的指针，而您传入的是%T", obj)
+	}
+	return v, nil
+}
+
+// isZeroField 成员是否为零值，嵌入的struct指针为nil时也视为零值
+func isZeroField(elem reflect.Value, field *structFieldInfo) bool {
+	v := structFieldValue(elem, field)
+	return !v.IsValid() || v.IsZero()
+}
+
+// columnParams 按成员的位置取得列的值，用作map参数
+//
+// 不按名称查找成员，避免列名与其他成员名相同时绑定错误的值。
+func (o *osmBase) columnParams(elem reflect.Value, cs ...*crudColumn) (map[string]interface{}, error) {
+	params := make(map[string]interface{}, len(cs))
+	for _, c := range cs {
+		value, err := o.columnValue(elem, c.field)
+		if err != nil {
+			return nil, fmt.Errorf("列'%s' : %s", c.name, err.Error())
+		}
+		params[c.param] = value
+	}
+	return params, nil
+}
+
+// whereByPK 生成按主键查找的条件，如"id" = #{c0}
+func (o *osmBase) whereByPK(t *crudTable) (string, error) {
+	if len(t.pks) == 0 {
+		return "", fmt.Errorf("表%s对应的struct没有pk选项的成员，如`db:\"id,pk\"`", t.table)
+	}
+	conds := make([]string, len(t.pks))
+	for i, c := range t.pks {
+		conds[i] = o.quoteIdent(c.name) + " = #{" + c.param + "}"
+	}
+	return strings.Join(conds, " AND "), nil
+}
+
+// InsertStruct 按struct的db标签生成并执行INSERT，table为空时使用obj的TableName()
+//
+// readonly的列不写入，omitempty、default的列为零值时不写入(default的列使用数据库的默认值)，auto的列为零值时由数据库生成，
+// obj为指针时会将生成的值写回auto的成员(MySQL、TiDB、SQLite使用LastInsertId，PostgreSQL、CockroachDB使用RETURNING，MSSQL使用OUTPUT)。
+// autoCreateTime、autoUpdateTime的成员为零值时填入Options.Clock的时间，autoActor的成员为零值时填入Options.ActorFromContext的值。
+// 返回值与Insert相同，为insertID和影响的行数。
+//
+// 代码
+//
+//	type User struct {
+//		ID    int64  `db:"id,pk,auto"`
+//		Email string `db:"email"`
+//	}
+//
+//	func (User) TableName() string { return "user" }
+//
+//	user := &User{Email: "test@foxmail.com"}
+//	_, _, err := o.InsertStruct("", user)
+//	log.Println(user.ID)
+func (o *osmBase) InsertStruct(table string, obj interface{}) (int64, int64, error) {
+	return o.insertStruct(getCallerInfo(2), table, obj)
+}
+
+func (o *osmBase) insertStruct(logPrefix, table string, obj interface{}) (int64, int64, error) {
+	elem, err := structOf(obj)
+	if err != nil {
+		return 0, 0, fmt.Errorf("InsertStruct error : %s", err.Error())
+	}
+	t, err := o.crudTableOf(table, elem.Type())
+	if err != nil {
+		return 0, 0, fmt.Errorf("InsertStruct error : %s", err.Error())
+	}
+	canFill := elem.CanAddr()
+	if elem, err = o.beforeInsert(elem); err != nil {
+		return 0, 0, fmt.Errorf("InsertStruct error : BeforeInsert : %w", err)
+	}
+	if elem, err = o.fillAudit(logPrefix, t, elem, true); err != nil {
+		return 0, 0, fmt.Errorf("InsertStruct error : %s", err.Error())
+	}
+
+	var names, values []string
+	var written []*crudColumn
+	fillAuto := false
+	for _, c := range t.columns {
+		f := c.field
+		if f.readonly {
+			continue
+		}
+		if (f.auto || f.omitempty || f.hasDefault || f.softDelete) && isZeroField(elem, f) {
+			if c == t.auto {
+				fillAuto = canFill
+			}
+			continue
+		}
+		names = append(names, o.quoteIdent(c.name))
+		values = append(values, "#{"+c.param+"}")
+		written = append(written, c)
+	}
+	if len(names) == 0 {
+		return 0, 0, fmt.Errorf("InsertStruct error : 表%s没有可写入的列", t.table)
+	}
+
+	head := "INSERT INTO " + t.table + " (" + strings.Join(names, ", ") + ")"
+	tail := " VALUES (" + strings.Join(values, ", ") + ")"
+	columnValues, err := o.columnParams(elem, written...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("InsertStruct error : %s", err.Error())
+	}
+	params := []interface{}{columnValues}
+	var insertID, count int64
+	switch {
+	case fillAuto && (o.dbType == dbTypePostgres || o.dbType == dbTypeCockroach):
+		autoValue := structFieldAlloc(elem, t.auto.field).Addr().Interface()
+		count, err = o.selectBySQL(logPrefix, head+tail+" RETURNING "+o.quoteIdent(t.auto.name), resultTypeValue, params)(autoValue)
+		insertID = intValue(structFieldValue(elem, t.auto.field))
+	case fillAuto && o.dbType == dbTypeMssql:
+		autoValue := structFieldAlloc(elem, t.auto.field).Addr().Interface()
+		count, err = o.selectBySQL(logPrefix, head+" OUTPUT INSERTED."+o.quoteIdent(t.auto.name)+tail, resultTypeValue, params)(autoValue)
+		insertID = intValue(structFieldValue(elem, t.auto.field))
+	default:
+		insertID, count, err = o.insert(logPrefix, head+tail, params)
+		if err == nil && fillAuto && insertID != 0 {
+			if err := o.setField(logPrefix, elem, t.auto.field, insertID); err != nil {
+				return insertID, count, fmt.Errorf("InsertStruct error : %s", err.Error())
+			}
+		}
+	}
+	if err != nil {
+		return insertID, count, err
+	}
+	if err := o.afterInsert(elem, insertID); err != nil {
+		return insertID, count, fmt.Errorf("InsertStruct error : AfterInsert : %w", err)
+	}
+	return insertID, count, nil
+}
+
+// intValue 取整数成员的值，用作insertID
+func intValue(v reflect.Value) int64 {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	}
+	return 0
+}
+
+// UpdateStruct 按struct的db标签生成并执行UPDATE，按pk的成员查找行，table为空时使用obj的TableName()
+//
+// columns为空时更新pk、auto、readonly、softdelete、autoCreateTime、autoActor以外的全部列(omitempty的列为零值时不更新)，
+// 否则只更新columns中的列，columns可以是列名或成员名。autoUpdateTime的列总会更新为当前时间。
+// 返回影响的行数，已软删除的行不会被更新。
+//
+// 有version选项的成员时，按成员的值校验版本号并将其加1，如`db:"version,version"`，
+// 没有匹配的行(已被其他人修改)时返回ErrStaleObject，成功后obj中的版本号也会加1(obj需为指针)。
+//
+// 代码
+//
+//	user.Email = "test2@foxmail.com"
+//	count, err := o.UpdateStruct("", user, "email")
+func (o *osmBase) UpdateStruct(table string, obj interface{}, columns ...string) (int64, error) {
+	return o.updateStruct(getCallerInfo(2), table, obj, false, columns)
+}
+
+// UpdateStructNonZero 与UpdateStruct相同，只更新值不为零值的列
+func (o *osmBase) UpdateStructNonZero(table string, obj interface{}) (int64, error) {
+	return o.updateStruct(getCallerInfo(2), table, obj, true, nil)
+}
+
+func (o *osmBase) updateStruct(logPrefix, table string, obj interface{}, nonZero bool, columns []string) (int64, error) {
+	elem, err := structOf(obj)
+	if err != nil {
+		return 0, fmt.Errorf("UpdateStruct error : %s", err.Error())
+	}
+	t, err := o.crudTableOf(table, elem.Type())
+	if err != nil {
+		return 0, fmt.Errorf("UpdateStruct error : %s", err.Error())
+	}
+	where, err := o.whereByPK(t)
+	if err != nil {
+		return 0, fmt.Errorf("UpdateStruct error : %s", err.Error())
+	}
+	if elem, err = o.beforeUpdate(elem); err != nil {
+		return 0, fmt.Errorf("UpdateStruct error : BeforeUpdate : %w", err)
+	}
+	if elem, err = o.fillAudit(logPrefix, t, elem, false); err != nil {
+		return 0, fmt.Errorf("UpdateStruct error : %s", err.Error())
+	}
+
+	var sets []string
+	var written []*crudColumn
+	if len(columns) > 0 {
+		included := map[*crudColumn]bool{}
+		for _, name := range columns {
+			c := t.column(name)
+			if c == nil {
+				return 0, fmt.Errorf("UpdateStruct error : 表%s没有列'%s'", t.table, name)
+			}
+			if c.field.pk || c.field.readonly || c.field.version {
+				return 0, fmt.Errorf("UpdateStruct error : 列'%s'是pk、readonly或version，不能更新", name)
+			}
+			included[c] = true
+			sets = append(sets, o.quoteIdent(c.name)+" = #{"+c.param+"}")
+			written = append(written, c)
+		}
+		for _, c := range t.columns {
+			if c.field.updateTime && !included[c] {
+				sets = append(sets, o.quoteIdent(c.name)+" = #{"+c.param+"}")
+				written = append(written, c)
+			}
+		}
+	} else {
+		for _, c := range t.columns {
+			f := c.field
+			if f.pk || f.auto || f.readonly || f.version || f.softDelete || f.createTime || f.actor {
+				continue
+			}
+			if (nonZero || f.omitempty) && isZeroField(elem, f) {
+				continue
+			}
+			sets = append(sets, o.quoteIdent(c.name)+" = #{"+c.param+"}")
+			written = append(written, c)
+		}
+	}
+	if len(sets) == 0 {
+		return 0, fmt.Errorf("UpdateStruct error : 表%s没有需要更新的列", t.table)
+	}
+
+	if t.version != nil {
+		name := o.quoteIdent(t.version.name)
+		sets = append(sets, name+" = "+name+" + 1")
+		where += " AND " + name + " = #{" + t.version.param + "}"
+		written = append(written, t.version)
+	}
+	where += o.notDeleted(t)
+
+	params, err := o.columnParams(elem, append(written, t.pks...)...)
+	if err != nil {
+		return 0, fmt.Errorf("UpdateStruct error : %s", err.Error())
+	}
+	sql := "UPDATE " + t.table + " SET " + strings.Join(sets, ", ") + " WHERE " + where
+	count, err := o.exec(logPrefix, sql, []interface{}{params})
+	if err != nil || t.version == nil {
+		return count, err
+	}
+	if count == 0 {
+		return 0, ErrStaleObject
+	}
+	incrementVersion(elem, t.version.field)
+	return count, nil
+}
+
+// ErrStaleObject UpdateStruct时版本号不匹配，数据已被其他人修改或已删除
+var ErrStaleObject = errors.New("osm: stale object, version mismatch")
+
+// incrementVersion 写入成功后将obj中的版本号加1
+func incrementVersion(elem reflect.Value, field *structFieldInfo) {
+	v := structFieldValue(elem, field)
+	if !v.IsValid() || !v.CanSet() {
+		return
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(v.Uint() + 1)
+	}
+}
+
+// column 按列名或成员名查找列
+func (t *crudTable) column(name string) *crudColumn {
+	for _, c := range t.columns {
+		if c.name == name {
+			return c
+		}
+	}
+	for _, c := range t.columns {
+		if c.field.n == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// DeleteByPK 按obj中pk成员的值删除行，table为空时使用obj的TableName()，返回影响的行数
+//
+// 有softdelete选项的成员时不删除行，而是将该列设为当前时间(已软删除的行不变)，obj为指针时同时写入该成员；
+// 通过Unscoped()调用时直接删除行。
+//
+// 代码
+//
+//	count, err := o.DeleteByPK("", &User{ID: 3})
+func (o *osmBase) DeleteByPK(table string, obj interface{}) (int64, error) {
+	return o.deleteByPK(getCallerInfo(2), table, obj)
+}
+
+func (o *osmBase) deleteByPK(logPrefix, table string, obj interface{}) (int64, error) {
+	elem, err := structOf(obj)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteByPK error : %s", err.Error())
+	}
+	t, err := o.crudTableOf(table, elem.Type())
+	if err != nil {
+		return 0, fmt.Errorf("DeleteByPK error : %s", err.Error())
+	}
+	where, err := o.whereByPK(t)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteByPK error : %s", err.Error())
+	}
+	if t.deleted != nil && !o.unscoped {
+		return o.setDeleted(logPrefix, t, elem, where)
+	}
+	params, err := o.columnParams(elem, t.pks...)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteByPK error : %s", err.Error())
+	}
+	return o.exec(logPrefix, "DELETE FROM "+t.table+" WHERE "+where, []interface{}{params})
+}
+
+// GetByPK 按主键查询一行到container，container为struct的指针，table为空时使用TableName()
+//
+// pk按pk成员定义的顺序传入，不传时使用container中pk成员的值。返回值与SelectStruct相同，没有查到时为0。
+// 有softdelete选项的成员时不返回已软删除的行，通过Unscoped()调用时返回。
+//
+// 代码
+//
+//	var user User
+//	count, err := o.GetByPK("", &user, 3)
+func (o *osmBase) GetByPK(table string, container interface{}, pk ...interface{}) (int64, error) {
+	return o.getByPK(getCallerInfo(2), table, container, pk)
+}
+
+func (o *osmBase) getByPK(logPrefix, table string, container interface{}, pk []interface{}) (int64, error) {
+	containerType := reflect.TypeOf(container)
+	if containerType == nil || containerType.Kind() != reflect.Ptr {
+		return 0, fmt.Errorf("GetByPK error : container应为struct的指针，而您传入的是%T", container)
+	}
+	structType := containerType.Elem()
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	t, err := o.crudTableOf(table, structType)
+	if err != nil {
+		return 0, fmt.Errorf("GetByPK error : %s", err.Error())
+	}
+	where, err := o.whereByPK(t)
+	if err != nil {
+		return 0, fmt.Errorf("GetByPK error : %s", err.Error())
+	}
+
+	var param interface{}
+	if len(pk) == 0 {
+		elem, err := structOf(container)
+		if err != nil {
+			return 0, fmt.Errorf("GetByPK error : %s", err.Error())
+		}
+		if param, err = o.columnParams(elem, t.pks...); err != nil {
+			return 0, fmt.Errorf("GetByPK error : %s", err.Error())
+		}
+	} else {
+		if len(pk) != len(t.pks) {
+			return 0, fmt.Errorf("GetByPK error : 表%s有%d个pk列，而您传入了%d个值", t.table, len(t.pks), len(pk))
+		}
+		pkMap := make(map[string]interface{}, len(pk))
+		for i, c := range t.pks {
+			pkMap[c.param] = pk[i]
+		}
+		param = pkMap
+	}
+
+	names := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		names[i] = o.quoteIdent(c.name)
+	}
+	sql := "SELECT " + strings.Join(names, ", ") + " FROM " + t.table + " WHERE " + where + o.notDeleted(t)
+	return o.selectBySQL(logPrefix, sql, resultTypeStruct, []interface{}{param})(container)
+}
@@ -0,0 +1,277 @@
+package osm
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testCrudUser struct {
+	ID       int64  `db:"id,pk,auto"`
+	Email    string `db:"email"`
+	Nickname string `db:"nickname,omitempty"`
+	Score    int    `db:"score,readonly"`
+	Age      int
+}
+
+func (testCrudUser) TableName() string { return "user" }
+
+func TestInsertStruct(t *testing.T) {
+	t.Run("mysql fills auto id", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `user` (`email`, `age`) VALUES (?, ?)")).
+			ExpectExec().WithArgs("a@b.c", 18).
+			WillReturnResult(sqlmock.NewResult(7, 1))
+
+		user := &testCrudUser{Email: "a@b.c", Age: 18}
+		insertID, count, err := o.InsertStruct("", user)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if insertID != 7 || count != 1 || user.ID != 7 {
+			t.Errorf("insertID=%d count=%d ID=%d", insertID, count, user.ID)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("default column uses database default when zero", func(t *testing.T) {
+		type task struct {
+			ID     int64  `db:"id,pk,auto"`
+			Title  string `db:"title"`
+			Status int    `db:"status,default"`
+		}
+		o, mock := newMockOsm(t)
+		mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `task` (`title`) VALUES (?)")).
+			ExpectExec().WithArgs("a").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `task` (`title`, `status`) VALUES (?, ?)")).
+			ExpectExec().WithArgs("b", 2).WillReturnResult(sqlmock.NewResult(2, 1))
+
+		if _, _, err := o.InsertStruct("task", &task{Title: "a"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := o.InsertStruct("task", &task{Title: "b", Status: 2}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("postgres uses RETURNING", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.dbType = dbTypePostgres
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "app"."user" ("email", "nickname", "age") VALUES ($1, $2, $3) RETURNING "id"`)).
+			WithArgs("a@b.c", "nick", 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(42)))
+
+		user := &testCrudUser{Email: "a@b.c", Nickname: "nick"}
+		insertID, count, err := o.InsertStruct("app.user", user)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if insertID != 42 || count != 1 || user.ID != 42 {
+			t.Errorf("insertID=%d count=%d ID=%d", insertID, count, user.ID)
+		}
+	})
+
+	t.Run("mssql uses OUTPUT", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.dbType = dbTypeMssql
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO [user] ([email], [age]) OUTPUT INSERTED.[id] VALUES ($1, $2)`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(5)))
+
+		user := &testCrudUser{Email: "a@b.c"}
+		if _, _, err := o.InsertStruct("", user); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if user.ID != 5 {
+			t.Errorf("ID = %d, want 5", user.ID)
+		}
+	})
+
+	t.Run("explicit auto value is written", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `user` (`id`, `email`, `age`) VALUES (?, ?, ?)")).
+			ExpectExec().WithArgs(int64(9), "a@b.c", 0).
+			WillReturnResult(sqlmock.NewResult(9, 1))
+
+		if _, _, err := o.InsertStruct("", &testCrudUser{ID: 9, Email: "a@b.c"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("missing table name", func(t *testing.T) {
+		o, _ := newMockOsm(t)
+		_, _, err := o.InsertStruct("", &struct{ Name string }{"x"})
+		if err == nil || !strings.Contains(err.Error(), "TableName") {
+			t.Errorf("expected table name error, got %v", err)
+		}
+	})
+}
+
+func TestUpdateStruct(t *testing.T) {
+	t.Run("all columns", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `user` SET `email` = ?, `age` = ? WHERE `id` = ?")).
+			ExpectExec().WithArgs("a@b.c", 20, int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		count, err := o.UpdateStruct("", testCrudUser{ID: 3, Email: "a@b.c", Age: 20})
+		if err != nil || count != 1 {
+			t.Fatalf("count=%d err=%v", count, err)
+		}
+	})
+
+	t.Run("selected columns", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `user` SET `nickname` = ?, `age` = ? WHERE `id` = ?")).
+			ExpectExec().WithArgs("", 20, int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if _, err := o.UpdateStruct("", &testCrudUser{ID: 3, Age: 20}, "nickname", "Age"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("non zero", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `user` SET `nickname` = ? WHERE `id` = ?")).
+			ExpectExec().WithArgs("nick", int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if _, err := o.UpdateStructNonZero("", &testCrudUser{ID: 3, Nickname: "nick"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("rejects pk and unknown columns", func(t *testing.T) {
+		o, _ := newMockOsm(t)
+		if _, err := o.UpdateStruct("", &testCrudUser{ID: 3}, "id"); err == nil {
+			t.Error("expected error for pk column")
+		}
+		if _, err := o.UpdateStruct("", &testCrudUser{ID: 3}, "missing"); err == nil {
+			t.Error("expected error for unknown column")
+		}
+		if _, err := o.UpdateStruct("t", &struct{ Name string }{"x"}); err == nil || !strings.Contains(err.Error(), "pk") {
+			t.Errorf("expected missing pk error, got %v", err)
+		}
+	})
+
+	t.Run("tag column equal to another field name", func(t *testing.T) {
+		type swapped struct {
+			ID    int64  `db:"id,pk"`
+			Title string `db:"Name"`
+			Name  string
+		}
+		o, mock := newMockOsm(t)
+		mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `post` SET `Name` = ?, `name` = ? WHERE `id` = ?")).
+			ExpectExec().WithArgs("the title", "the name", int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if _, err := o.UpdateStruct("post", swapped{ID: 1, Title: "the title", Name: "the name"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestDeleteByPK(t *testing.T) {
+	o, mock := newMockOsm(t)
+	o.dbType = dbTypePostgres
+	type orderItem struct {
+		OrderID int64 `db:"order_id,pk"`
+		ItemID  int64 `db:"item_id,pk"`
+	}
+	mock.ExpectPrepare(regexp.QuoteMeta(`DELETE FROM "order_item" WHERE "order_id" = $1 AND "item_id" = $2`)).
+		ExpectExec().WithArgs(int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	count, err := o.DeleteByPK("order_item", orderItem{OrderID: 1, ItemID: 2})
+	if err != nil || count != 1 {
+		t.Fatalf("count=%d err=%v", count, err)
+	}
+}
+
+func TestGetByPK(t *testing.T) {
+	t.Run("pk argument", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, `email`, `nickname`, `score`, `age` FROM `user` WHERE `id` = ?")).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "nickname", "score", "age"}).AddRow(3, "a@b.c", "n", 99, 20))
+
+		var user testCrudUser
+		count, err := o.GetByPK("", &user, 3)
+		if err != nil || count != 1 {
+			t.Fatalf("count=%d err=%v", count, err)
+		}
+		if user.ID != 3 || user.Email != "a@b.c" || user.Score != 99 || user.Age != 20 {
+			t.Errorf("user = %+v", user)
+		}
+	})
+
+	t.Run("pk from container", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery("SELECT").WithArgs(int64(4)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "nickname", "score", "age"}))
+
+		user := testCrudUser{ID: 4}
+		count, err := o.GetByPK("", &user)
+		if err != nil || count != 0 {
+			t.Fatalf("count=%d err=%v", count, err)
+		}
+	})
+
+	t.Run("wrong pk count", func(t *testing.T) {
+		o, _ := newMockOsm(t)
+		var user testCrudUser
+		if _, err := o.GetByPK("", &user, 1, 2); err == nil {
+			t.Error("expected error for wrong pk count")
+		}
+	})
+}
+
+func TestUpdateStructVersion(t *testing.T) {
+	type doc struct {
+		ID      int64  `db:"id,pk"`
+		Title   string `db:"title"`
+		Version int    `db:"version,version"`
+	}
+	expectUpdate := func(mock sqlmock.Sqlmock, rows int64) {
+		mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `doc` SET `title` = ?, `version` = `version` + 1 WHERE `id` = ? AND `version` = ?")).
+			ExpectExec().WithArgs("new", int64(1), 3).
+			WillReturnResult(sqlmock.NewResult(0, rows))
+	}
+
+	t.Run("increments version", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		expectUpdate(mock, 1)
+		d := &doc{ID: 1, Title: "new", Version: 3}
+		count, err := o.UpdateStruct("doc", d)
+		if err != nil || count != 1 {
+			t.Fatalf("count=%d err=%v", count, err)
+		}
+		if d.Version != 4 {
+			t.Errorf("Version=%d, want 4", d.Version)
+		}
+	})
+
+	t.Run("stale object", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		expectUpdate(mock, 0)
+		d := &doc{ID: 1, Title: "new", Version: 3}
+		if _, err := o.UpdateStruct("doc", d); !errors.Is(err, ErrStaleObject) {
+			t.Fatalf("expected ErrStaleObject, got %v", err)
+		}
+		if d.Version != 3 {
+			t.Errorf("Version=%d, want 3", d.Version)
+		}
+	})
+
+	t.Run("rejects version column", func(t *testing.T) {
+		o, _ := newMockOsm(t)
+		if _, err := o.UpdateStruct("doc", &doc{ID: 1}, "version"); err == nil {
+			t.Error("expected error for version column")
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package osm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// quoteIdent 按数据库类型给表名、列名加引号，如MySQL为`name`，MSSQL为[name]，Oracle为"NAME"，其他为"name"
+//
+// 带.的名称逐段处理，如schema.table。Oracle中未加引号的名称按大写保存，
+// 因此只由字母、数字、_、$、#组成的名称转为大写后再加引号，与常规建立的表名、列名一致。
+func (o *osmBase) quoteIdent(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		switch o.dbType {
+		case dbTypeMysql, dbTypeTiDB, dbTypeClickHouse:
+			parts[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+		case dbTypeMssql:
+			parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+		case dbTypeOracle:
+			if isOracleName(part) {
+				part = strings.ToUpper(part)
+			}
+			parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		default: // PostgreSQL, SQLite, CockroachDB
+			parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// placeholder 第index个(从1开始)参数的占位符，MySQL、SQLite、TiDB为?，Oracle为:index，其他为$index
+func (o *osmBase) placeholder(index int) string {
+	switch o.dbType {
+	case dbTypeMysql, dbTypeSqlite, dbTypeTiDB:
+		return "?"
+	case dbTypeOracle:
+		return ":" + strconv.Itoa(index)
+	}
+	// PostgreSQL, MSSQL, CockroachDB, ClickHouse
+	return "$" + strconv.Itoa(index)
+}
+
+// skipQuoted sql中i处是字符串、引号内的名称或注释的开头时，返回其后的位置，否则返回i
+//
+// --行注释返回换行符的位置，未闭合时返回len(sql)。
+func skipQuoted(sql string, i int) int {
+	c := sql[i]
+	switch {
+	case c == '\'' || c == '"' || c == '`' || c == '[':
+		end := c
+		if c == '[' {
+			end = ']'
+		}
+		if n := strings.IndexByte(sql[i+1:], end); n >= 0 {
+			return i + n + 2
+		}
+		return len(sql)
+	case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+		if n := strings.IndexByte(sql[i:], '\n'); n >= 0 {
+			return i + n
+		}
+		return len(sql)
+	case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+		if n := strings.Index(sql[i+2:], "*/"); n >= 0 {
+			return i + n + 4
+		}
+		return len(sql)
+	}
+	return i
+}
+
+// codeIndex 查找sql中第一个不在字符串、引号和注释内的substr的位置，没有时返回-1
+func codeIndex(sql, substr string) int {
+	for i := 0; i < len(sql); {
+		if j := skipQuoted(sql, i); j != i {
+			i = j
+			continue
+		}
+		if strings.HasPrefix(sql[i:], substr) {
+			return i
+		}
+		i++
+	}
+	return -1
+}
+
+// topLevelKeyword 查找sql中最后一个不在括号、字符串、引号和注释内的关键字(如"ORDER BY")的位置，没有时返回-1
+func topLevelKeyword(sql, keyword string) int {
+	upper := strings.ToUpper(sql)
+	keyword = strings.ToUpper(keyword)
+	found := -1
+	depth := 0
+	for i := 0; i < len(sql); i++ {
+		if j := skipQuoted(sql, i); j != i {
+			i = j - 1
+			continue
+		}
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && strings.HasPrefix(upper[i:], keyword) &&
+				(i == 0 || !isIdentChar(sql[i-1])) &&
+				(i+len(keyword) == len(sql) || !isIdentChar(sql[i+len(keyword)])) {
+				found = i
+			}
+		}
+	}
+	return found
+}
+
+// isOracleName 名称是否可以不加引号在Oracle中使用，即以字母开头，由字母、数字、_、$、#组成
+func isOracleName(name string) bool {
+	if name == "" || !(name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if c := name[i]; !isIdentChar(c) && c != '$' && c != '#' {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// trimSQL 去掉sql首尾的空白和末尾的;，末行有--注释时加上换行，以便包装为子查询或追加分页
+func trimSQL(sql string) string {
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+	if strings.Contains(sql[strings.LastIndexByte(sql, '\n')+1:], "--") {
+		sql += "\n"
+	}
+	return sql
+}
+
+// trimOrderBy 去掉sql末尾的ORDER BY，用于COUNT子查询
+func trimOrderBy(sql string) string {
+	if i := topLevelKeyword(sql, "ORDER BY"); i >= 0 {
+		return trimSQL(sql[:i])
+	}
+	return sql
+}
+
+// countSQL 将sql包装为子查询，查询总行数
+func countSQL(sql string) string {
+	return "SELECT COUNT(*) FROM (" + trimOrderBy(trimSQL(sql)) + ") osm_count"
+}
+
+// limitSQL 按数据库类型给sql加上分页
+//
+// MSSQL、Oracle使用OFFSET ... FETCH NEXT，MSSQL没有ORDER BY时第一页使用TOP，其他页按(SELECT NULL)排序；
+// 其他数据库使用LIMIT ... OFFSET。
+func (o *osmBase) limitSQL(sql string, limit, offset int) string {
+	sql = trimSQL(sql)
+	switch o.dbType {
+	case dbTypeMssql:
+		if topLevelKeyword(sql, "ORDER BY") < 0 {
+			if offset == 0 && len(sql) >= 6 && strings.EqualFold(sql[:6], "SELECT") && (len(sql) == 6 || !isIdentChar(sql[6])) {
+				head, rest := sql[:6], sql[6:]
+				if trimmed := strings.TrimLeft(rest, " \t\r\n"); len(trimmed) >= 8 && strings.EqualFold(trimmed[:8], "DISTINCT") {
+					head, rest = head+rest[:len(rest)-len(trimmed)]+trimmed[:8], trimmed[8:]
+				}
+				return head + " TOP " + strconv.Itoa(limit) + rest
+			}
+			sql += " ORDER BY (SELECT NULL)"
+		}
+		return sql + " OFFSET " + strconv.Itoa(offset) + " ROWS FETCH NEXT " + strconv.Itoa(limit) + " ROWS ONLY"
+	case dbTypeOracle:
+		return sql + " OFFSET " + strconv.Itoa(offset) + " ROWS FETCH NEXT " + strconv.Itoa(limit) + " ROWS ONLY"
+	}
+	return sql + " LIMIT " + strconv.Itoa(limit) + " OFFSET " + strconv.Itoa(offset)
+}
@@ -0,0 +1,27 @@
+package osm
+
+import "testing"
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		dbType dbType
+		name   string
+		want   string
+	}{
+		{dbTypeMysql, "app.user", "`app`.`user`"},
+		{dbTypeMysql, "a`b", "`a``b`"},
+		{dbTypeMssql, "dbo.user", "[dbo].[user]"},
+		{dbTypePostgres, "public.user", `"public"."user"`},
+		// Oracle中未加引号的名称按大写保存
+		{dbTypeOracle, "app.user_log$", `"APP"."USER_LOG$"`},
+		{dbTypeOracle, "created_at", `"CREATED_AT"`},
+		{dbTypeOracle, "my table", `"my table"`},
+		{dbTypeOracle, "_id", `"_id"`},
+	}
+	for _, tc := range tests {
+		o := &osmBase{dbType: tc.dbType, options: &Options{}}
+		if got := o.quoteIdent(tc.name); got != tc.want {
+			t.Errorf("quoteIdent(%d, %q) = %q, want %q", tc.dbType, tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package osm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type EmbedAudit struct {
+	CreatedBy string `db:"created_by"`
+	Note      string
+}
+
+type EmbedBase struct {
+	ID   int64 `db:"id"`
+	Note string
+	*EmbedAudit
+}
+
+type testOther struct {
+	Note string
+}
+
+type testEmbedded struct {
+	*EmbedBase
+	Name string `db:"name"`
+}
+
+func TestGetStructFieldMapEmbedded(t *testing.T) {
+	tagMap := map[string]*structFieldInfo{}
+	nameMap := map[string]*structFieldInfo{}
+	getStructFieldMap(reflect.TypeOf(testEmbedded{}), tagMap, nameMap)
+
+	if f := tagMap["id"]; f == nil || !reflect.DeepEqual(f.index, []int{0, 0}) {
+		t.Errorf("id index = %v", f)
+	}
+	if f := tagMap["created_by"]; f == nil || !reflect.DeepEqual(f.index, []int{0, 2, 0}) {
+		t.Errorf("created_by index = %v", f)
+	}
+	// EmbedBase.Note比EmbedAudit.Note浅，按Go的规则覆盖后者
+	if f := nameMap["Note"]; f == nil || f.ambiguous || !reflect.DeepEqual(f.index, []int{0, 1}) {
+		t.Errorf("Note = %+v", f)
+	}
+
+	type ambiguous struct {
+		testOther
+		EmbedAudit
+	}
+	tagMap = map[string]*structFieldInfo{}
+	nameMap = map[string]*structFieldInfo{}
+	getStructFieldMap(reflect.TypeOf(ambiguous{}), tagMap, nameMap)
+	if f := nameMap["Note"]; f == nil || !f.ambiguous {
+		t.Errorf("Note should be ambiguous, got %+v", f)
+	}
+	if _, err := findFieldBy(SnakeMapper, tagMap, nameMap, "note"); err == nil || !strings.Contains(err.Error(), "Note") {
+		t.Errorf("expected ambiguous error, got %v", err)
+	}
+	if f, err := findFieldBy(SnakeMapper, tagMap, nameMap, "created_by"); err != nil || f == nil {
+		t.Errorf("created_by = %v, %v", f, err)
+	}
+}
+
+func TestGetStructFieldMapRecursive(t *testing.T) {
+	type Node struct {
+		Name string
+		*Node
+	}
+	tagMap := map[string]*structFieldInfo{}
+	nameMap := map[string]*structFieldInfo{}
+	getStructFieldMap(reflect.TypeOf(Node{}), tagMap, nameMap)
+	if f := nameMap["Name"]; f == nil || len(f.index) != 1 {
+		t.Errorf("Name = %+v", f)
+	}
+}
+
+func TestScanEmbeddedPointer(t *testing.T) {
+	o, mock := newMockOsm(t)
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "note", "created_by"}).
+			AddRow(1, "a", "n1", "admin").
+			AddRow(2, "b", "n2", "root"),
+	)
+
+	var items []testEmbedded
+	if _, err := o.SelectStructs("SELECT id, name, note, created_by FROM t")(&items); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if items[0].EmbedBase == nil || items[0].ID != 1 || items[0].Note != "n1" {
+		t.Errorf("items[0] = %+v", items[0].EmbedBase)
+	}
+	if items[1].EmbedAudit == nil || items[1].CreatedBy != "root" {
+		t.Errorf("items[1].EmbedAudit = %+v", items[1].EmbedAudit)
+	}
+	if items[0].EmbedBase == items[1].EmbedBase {
+		t.Error("rows should not share embedded pointers")
+	}
+}
+
+func TestScanEmbeddedPointerNotAllocatedWithoutColumns(t *testing.T) {
+	o, mock := newMockOsm(t)
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	var item testEmbedded
+	if _, err := o.SelectStruct("SELECT id, name FROM t")(&item); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if item.EmbedBase == nil || item.ID != 1 {
+		t.Fatalf("EmbedBase = %+v", item.EmbedBase)
+	}
+	if item.EmbedAudit != nil {
+		t.Errorf("EmbedAudit should stay nil, got %+v", item.EmbedAudit)
+	}
+}
+
+func TestScanAmbiguousColumn(t *testing.T) {
+	type ambiguous struct {
+		testOther
+		EmbedAudit
+	}
+	o, mock := newMockOsm(t)
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"note"}).AddRow("x"))
+
+	var item ambiguous
+	_, err := o.SelectStruct("SELECT note FROM t")(&item)
+	if err == nil || !strings.Contains(err.Error(), "note") {
+		t.Errorf("expected ambiguous column error, got %v", err)
+	}
+}
+
+func TestBindEmbeddedPointer(t *testing.T) {
+	o, _ := newMockOsm(t)
+	_, params, err := o.readSQLParamsBySQL("test", "UPDATE t SET name=#{Name}, created_by=#{created_by} WHERE id=#{id}",
+		testEmbedded{Name: "a", EmbedBase: &EmbedBase{ID: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 3 || params[0] != "a" || params[2] != int64(3) {
+		t.Fatalf("params = %#v", params)
+	}
+	if v := reflect.ValueOf(params[1]); v.Kind() != reflect.Ptr || !v.IsNil() {
+		t.Errorf("created_by should bind NULL, got %#v", params[1])
+	}
+}
@@ -1,5 +1,5 @@
 module github.com/yinshuwei/osm/v2
 
-go 1.22
+go 1.23
 
-require github.com/DATA-DOG/go-sqlmock v1.5.2 // indirect
+require github.com/DATA-DOG/go-sqlmock v1.5.2
@@ -0,0 +1,90 @@
+package osm
+
+import (
+	"context"
+	"reflect"
+)
+
+// BeforeInserter InsertStruct、Upsert写入前调用，可以用于校验和规范化成员的值，返回错误时不执行写入
+//
+// 方法的接收者应为指针，这样修改的值才会被写入。ctx为WithContext()设置的context。
+// 在Transaction中返回错误时，Transaction会回滚事务。
+//
+// 代码
+//
+//	func (u *User) BeforeInsert(ctx context.Context) error {
+//		u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+//		if u.Email == "" {
+//			return errors.New("email is required")
+//		}
+//		return nil
+//	}
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// BeforeUpdater UpdateStruct、UpdateStructNonZero更新前调用，返回错误时不执行更新
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterInserter InsertStruct写入成功后调用，id为insertID，auto的成员已写回
+type AfterInserter interface {
+	AfterInsert(ctx context.Context, id int64) error
+}
+
+// AfterScanner SelectStruct、SelectStructs、GetByPK等读取一行到struct后调用，可以用于计算派生的成员，返回错误时查询返回该错误
+type AfterScanner interface {
+	AfterScan() error
+}
+
+var (
+	beforeInserterType = reflect.TypeOf((*BeforeInserter)(nil)).Elem()
+	beforeUpdaterType  = reflect.TypeOf((*BeforeUpdater)(nil)).Elem()
+	afterInserterType  = reflect.TypeOf((*AfterInserter)(nil)).Elem()
+	afterScannerType   = reflect.TypeOf((*AfterScanner)(nil)).Elem()
+)
+
+// addressable 返回可修改的elem，elem不可修改(obj不是指针)时返回副本
+func addressable(elem reflect.Value) reflect.Value {
+	if elem.CanAddr() {
+		return elem
+	}
+	copied := reflect.New(elem.Type()).Elem()
+	copied.Set(elem)
+	return copied
+}
+
+// beforeInsert 调用BeforeInsert，返回用于绑定参数的struct
+func (o *osmBase) beforeInsert(elem reflect.Value) (reflect.Value, error) {
+	if !reflect.PointerTo(elem.Type()).Implements(beforeInserterType) {
+		return elem, nil
+	}
+	elem = addressable(elem)
+	return elem, elem.Addr().Interface().(BeforeInserter).BeforeInsert(o.context())
+}
+
+// beforeUpdate 调用BeforeUpdate，返回用于绑定参数的struct
+func (o *osmBase) beforeUpdate(elem reflect.Value) (reflect.Value, error) {
+	if !reflect.PointerTo(elem.Type()).Implements(beforeUpdaterType) {
+		return elem, nil
+	}
+	elem = addressable(elem)
+	return elem, elem.Addr().Interface().(BeforeUpdater).BeforeUpdate(o.context())
+}
+
+// afterInsert 调用AfterInsert
+func (o *osmBase) afterInsert(elem reflect.Value, id int64) error {
+	if !reflect.PointerTo(elem.Type()).Implements(afterInserterType) {
+		return nil
+	}
+	return addressable(elem).Addr().Interface().(AfterInserter).AfterInsert(o.context(), id)
+}
+
+// afterScan 调用AfterScan，elem为读取了一行的struct
+func afterScan(elem reflect.Value) error {
+	if !reflect.PointerTo(elem.Type()).Implements(afterScannerType) {
+		return nil
+	}
+	return addressable(elem).Addr().Interface().(AfterScanner).AfterScan()
+}
@@ -0,0 +1,131 @@
+package osm
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var errTestInvalidEmail = errors.New("invalid email")
+
+type testHookUser struct {
+	ID       int64  `db:"id,pk,auto"`
+	Email    string `db:"email"`
+	Domain   string `db:"-"`
+	calls    []string
+	insertID int64
+}
+
+func (testHookUser) TableName() string { return "user" }
+
+func (u *testHookUser) BeforeInsert(ctx context.Context) error {
+	u.calls = append(u.calls, "BeforeInsert")
+	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	if !strings.Contains(u.Email, "@") {
+		return errTestInvalidEmail
+	}
+	return nil
+}
+
+func (u *testHookUser) BeforeUpdate(ctx context.Context) error {
+	u.calls = append(u.calls, "BeforeUpdate")
+	u.Email = strings.ToLower(u.Email)
+	return nil
+}
+
+func (u *testHookUser) AfterInsert(ctx context.Context, id int64) error {
+	u.calls = append(u.calls, "AfterInsert")
+	u.insertID = id
+	return nil
+}
+
+func (u *testHookUser) AfterScan() error {
+	u.Domain = u.Email[strings.Index(u.Email, "@")+1:]
+	return nil
+}
+
+func TestInsertHooks(t *testing.T) {
+	o, mock := newMockOsm(t)
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `user` (`email`) VALUES (?)")).
+		ExpectExec().WithArgs("a@b.c").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+
+	user := &testHookUser{Email: " A@B.C "}
+	if _, _, err := o.InsertStruct("", user); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(user.calls, ",") != "BeforeInsert,AfterInsert" || user.insertID != 5 || user.ID != 5 {
+		t.Errorf("calls=%v insertID=%d ID=%d", user.calls, user.insertID, user.ID)
+	}
+
+	// BeforeInsert返回错误时不执行写入
+	if _, _, err := o.InsertStruct("", &testHookUser{Email: "bad"}); !errors.Is(err, errTestInvalidEmail) {
+		t.Errorf("expected errTestInvalidEmail, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpdateHook(t *testing.T) {
+	o, mock := newMockOsm(t)
+	mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `user` SET `email` = ? WHERE `id` = ?")).
+		ExpectExec().WithArgs("a@b.c", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// 非指针参数在副本中调用
+	if _, err := o.UpdateStruct("", testHookUser{ID: 1, Email: "A@B.C"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAfterScan(t *testing.T) {
+	o, mock := newMockOsm(t)
+	mock.ExpectQuery("SELECT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(int64(1), "a@b.c"))
+	mock.ExpectQuery("SELECT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(int64(1), "a@b.c").AddRow(int64(2), "d@e.f"))
+
+	var user testHookUser
+	if _, err := o.SelectStruct("SELECT id, email FROM user")(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Domain != "b.c" {
+		t.Errorf("Domain = %q", user.Domain)
+	}
+
+	var users []*testHookUser
+	if _, err := o.SelectStructs("SELECT id, email FROM user")(&users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Domain != "b.c" || users[1].Domain != "e.f" {
+		t.Errorf("users = %+v", users)
+	}
+}
+
+func TestHookRollsBackTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{}
+	opts.tidy()
+	o := &Osm{osmBase: osmBase{db: db, dbType: dbTypeMysql, options: &opts}}
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err = o.Transaction(func(tx *Tx) error {
+		_, _, err := tx.InsertStruct("", &testHookUser{Email: "bad"})
+		return err
+	})
+	if !errors.Is(err, errTestInvalidEmail) {
+		t.Errorf("expected errTestInvalidEmail, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,151 @@
+package osm
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// identPattern ${name}未指定允许值时，标识符应由字母、数字、下划线组成，可以用.分隔，如schema.table
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// expandIdents 将sql中的${name}替换为参数中的标识符，如列名、表名，按数据库类型加引号
+//
+// 支持的写法：
+//
+//	${name}         值应符合identPattern
+//	${name:a,b,c}   值应为a、b、c之一
+//	${name|dir}     排序方向，值应为ASC或DESC(不区分大小写)，不加引号
+//
+// 值从struct或map参数中按名称读取，应为字符串。字符串、引号和注释中的${不处理。
+func (o *osmBase) expandIdents(sqlOrg string, params []interface{}) (string, error) {
+	var param reflect.Value
+	if len(params) == 1 {
+		param = reflect.ValueOf(params[0])
+	}
+	for param.IsValid() && (param.Kind() == reflect.Ptr || param.Kind() == reflect.Interface) {
+		param = param.Elem()
+	}
+	if !param.IsValid() || (param.Kind() != reflect.Struct && param.Kind() != reflect.Map) {
+		return "", fmt.Errorf("sql '%s' error : ${}需要一个struct或map参数", sqlOrg)
+	}
+
+	var sb strings.Builder
+	sqlTemp := sqlOrg
+	errorIndex := 0
+	for {
+		si := codeIndex(sqlTemp, "${")
+		if si < 0 {
+			break
+		}
+		sb.WriteString(sqlTemp[:si])
+		sqlTemp = sqlTemp[si+2:]
+		errorIndex += si + 2
+		ei := strings.Index(sqlTemp, "}")
+		if ei < 0 {
+			return "", markSQLError(sqlOrg, errorIndex)
+		}
+		content := sqlTemp[:ei]
+		sqlTemp = sqlTemp[ei+1:]
+		errorIndex += ei + 1
+
+		ident, err := o.expandIdent(param, strings.TrimSpace(content))
+		if err != nil {
+			return "", fmt.Errorf("sql '%s' error : ${%s} : %s", sqlOrg, content, err.Error())
+		}
+		sb.WriteString(ident)
+	}
+	sb.WriteString(sqlTemp)
+	return sb.String(), nil
+}
+
+// expandIdent 按${}的内容取得并校验标识符
+func (o *osmBase) expandIdent(param reflect.Value, content string) (string, error) {
+	name, filter, isFilter := strings.Cut(content, "|")
+	var allowed []string
+	if !isFilter {
+		var list string
+		var hasList bool
+		name, list, hasList = strings.Cut(content, ":")
+		if hasList {
+			for _, item := range strings.Split(list, ",") {
+				allowed = append(allowed, strings.TrimSpace(item))
+			}
+		}
+	}
+	name = strings.TrimSpace(name)
+
+	value, err := o.identValue(param, name)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case isFilter:
+		if strings.TrimSpace(filter) != "dir" {
+			return "", fmt.Errorf("不支持的过滤器'%s'", filter)
+		}
+		dir := strings.ToUpper(strings.TrimSpace(value))
+		if dir != "ASC" && dir != "DESC" {
+			return "", fmt.Errorf("排序方向应为ASC或DESC，而参数的值是'%s'", value)
+		}
+		return dir, nil
+	case allowed != nil:
+		for _, item := range allowed {
+			if value == item {
+				return o.quoteIdent(value), nil
+			}
+		}
+		return "", fmt.Errorf("参数的值'%s'不在允许的值%v中", value, allowed)
+	}
+	if !identPattern.MatchString(value) {
+		return "", fmt.Errorf("参数的值'%s'不是合法的标识符", value)
+	}
+	return o.quoteIdent(value), nil
+}
+
+// identValue 从struct或map参数中取得名为name的字符串
+func (o *osmBase) identValue(param reflect.Value, name string) (string, error) {
+	var v reflect.Value
+	if param.Kind() == reflect.Map {
+		if param.Type().Key().Kind() != reflect.String {
+			return "", fmt.Errorf("map参数的key应为字符串")
+		}
+		v = param.MapIndex(reflect.ValueOf(name).Convert(param.Type().Key()))
+		if !v.IsValid() {
+			return "", fmt.Errorf("Key '%s' no exist", name)
+		}
+	} else {
+		tagMap := map[string]*structFieldInfo{}
+		nameMap := map[string]*structFieldInfo{}
+		if err := getStructFieldMap(param.Type(), tagMap, nameMap); err != nil {
+			return "", err
+		}
+		field, ok := tagMap[name]
+		if !ok {
+			field, ok = nameMap[name]
+		}
+		if !ok {
+			var err error
+			if field, err = findFieldBy(o.options.nameMapper(), tagMap, nameMap, name); err != nil {
+				return "", err
+			}
+		}
+		if field != nil {
+			v = structFieldValue(param, field)
+		}
+		if !v.IsValid() {
+			return "", fmt.Errorf("Field '%s' no exist", name)
+		}
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", fmt.Errorf("参数'%s'为nil", name)
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		return "", fmt.Errorf("参数'%s'应为字符串，而您传入的是%s", name, v.Type())
+	}
+	return v.String(), nil
+}
@@ -0,0 +1,71 @@
+package osm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandIdents(t *testing.T) {
+	type query struct {
+		Sort  string `db:"sort"`
+		Dir   string
+		Shard string
+		Age   int
+	}
+
+	o := &osmBase{dbType: dbTypeMysql, options: &Options{}}
+	sql, params, err := o.readSQLParamsBySQL("", "SELECT * FROM ${Shard} WHERE age > #{Age} ORDER BY ${sort:id,name} ${Dir|dir}",
+		query{Sort: "name", Dir: "desc", Shard: "app.user_01", Age: 18})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT * FROM `app`.`user_01` WHERE age > ? ORDER BY `name` DESC"; sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+	if len(params) != 1 || params[0] != 18 {
+		t.Errorf("params = %v", params)
+	}
+
+	// 只有${}时参数不作为原生占位符的参数
+	o.dbType = dbTypePostgres
+	sql, params, err = o.readSQLParamsBySQL("", "SELECT * FROM ${table}", map[string]interface{}{"table": "logs_2024"})
+	if err != nil || sql != `SELECT * FROM "logs_2024"` || len(params) != 0 {
+		t.Errorf("sql=%q params=%v err=%v", sql, params, err)
+	}
+	// 字符串、引号和注释中的${不处理，原生占位符的sql不受影响
+	o.dbType = dbTypeMysql
+	sql, params, err = o.readSQLParamsBySQL("", "SELECT '${x}' FROM dual WHERE id = ?", 1)
+	if err != nil || sql != "SELECT '${x}' FROM dual WHERE id = ?" || len(params) != 1 || params[0] != 1 {
+		t.Errorf("sql=%q params=%v err=%v", sql, params, err)
+	}
+	sql, _, err = o.readSQLParamsBySQL("", "SELECT `${a}` FROM ${table} /* ${b} */ -- ${c}\nWHERE note = '${d}'",
+		map[string]interface{}{"table": "logs"})
+	if want := "SELECT `${a}` FROM `logs` /* ${b} */ -- ${c}\nWHERE note = '${d}'"; err != nil || sql != want {
+		t.Errorf("sql=%q err=%v, want %q", sql, err, want)
+	}
+}
+
+func TestExpandIdentsRejects(t *testing.T) {
+	o := &osmBase{dbType: dbTypeMysql, options: &Options{}}
+	tests := []struct {
+		sql   string
+		param interface{}
+		want  string
+	}{
+		{"ORDER BY ${sort}", map[string]string{"sort": "id; DROP TABLE user"}, "不是合法的标识符"},
+		{"ORDER BY ${sort}", map[string]string{"sort": "a`b"}, "不是合法的标识符"},
+		{"ORDER BY ${sort:id,name}", map[string]string{"sort": "age"}, "不在允许的值"},
+		{"ORDER BY id ${dir|dir}", map[string]string{"dir": "ASC, (SELECT 1)"}, "ASC或DESC"},
+		{"ORDER BY id ${dir|upper}", map[string]string{"dir": "ASC"}, "不支持的过滤器"},
+		{"ORDER BY ${sort}", map[string]interface{}{"sort": 1}, "应为字符串"},
+		{"ORDER BY ${missing}", map[string]string{}, "no exist"},
+		{"ORDER BY ${sort}", "id", "struct或map"},
+		{"ORDER BY ${sort", map[string]string{"sort": "id"}, "ERROR"},
+	}
+	for _, tc := range tests {
+		_, _, err := o.readSQLParamsBySQL("", tc.sql, tc.param)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s with %v: err = %v, want %q", tc.sql, tc.param, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package osm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// jsonTypes 以JSON格式读写的类型
+var jsonTypes sync.Map
+
+// RegisterJSONType 注册以JSON格式读写的类型，传入该类型的值或指针，
+// 注册后该类型的成员或参数不需要`db:"xxx,json"`标签
+//
+// 如：
+//
+//	osm.RegisterJSONType(Settings{}, map[string]string{})
+func RegisterJSONType(values ...interface{}) {
+	for _, v := range values {
+		t := reflect.TypeOf(v)
+		if t == nil {
+			continue
+		}
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		jsonTypes.Store(t, true)
+	}
+}
+
+func isJSONType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	_, ok := jsonTypes.Load(t)
+	return ok
+}
+
+// scanJSON 将JSON列的值解析到dest，NULL得到零值(指针、map、切片为nil)
+func scanJSON(dest reflect.Value, src interface{}, destIsPtr bool, destType reflect.Type) error {
+	var data []byte
+	switch s := src.(type) {
+	case nil:
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	case []byte:
+		data = s
+	case string:
+		data = []byte(s)
+	default:
+		return fmt.Errorf("JSON列的值应为字符串，而数据库返回的是%T", src)
+	}
+
+	v := reflect.New(destType)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return fmt.Errorf("JSON列解析到%s失败 : %s", destType, err.Error())
+	}
+	if destIsPtr {
+		dest.Set(v)
+	} else {
+		dest.Set(v.Elem())
+	}
+	return nil
+}
+
+// bindJSON 将参数转为JSON字符串，nil指针、map、切片绑定为NULL
+func bindJSON(v reflect.Value) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("参数转为JSON失败 : %s", err.Error())
+	}
+	return string(data), nil
+}
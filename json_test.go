@@ -0,0 +1,86 @@
+package osm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testSettings struct {
+	Theme string `json:"theme"`
+	Size  int    `json:"size"`
+}
+
+type testRegisteredJSON struct {
+	Tags []string `json:"tags"`
+}
+
+// registerTestJSON 注册testRegisteredJSON为JSON类型，测试结束后移除
+func registerTestJSON(t *testing.T) {
+	RegisterJSONType(&testRegisteredJSON{})
+	t.Cleanup(func() {
+		jsonTypes.Delete(reflect.TypeOf(testRegisteredJSON{}))
+	})
+}
+
+type testUserWithJSON struct {
+	ID       int64             `db:"id"`
+	Settings testSettings      `db:"settings,json"`
+	Labels   map[string]string `db:"labels,json"`
+	Extra    *testSettings     `db:"extra,json"`
+	Meta     testRegisteredJSON
+}
+
+func TestJSONScan(t *testing.T) {
+	registerTestJSON(t)
+	o, mock := newMockOsm(t)
+	rows := sqlmock.NewRows([]string{"id", "settings", "labels", "extra", "meta"}).
+		AddRow(1, []byte(`{"theme":"dark","size":12}`), `{"a":"b"}`, nil, []byte(`{"tags":["x","y"]}`))
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+	var user testUserWithJSON
+	if _, err := o.Select("SELECT * FROM user").Struct(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Settings.Theme != "dark" || user.Settings.Size != 12 {
+		t.Errorf("settings: got %+v", user.Settings)
+	}
+	if user.Labels["a"] != "b" {
+		t.Errorf("labels: got %+v", user.Labels)
+	}
+	if user.Extra != nil {
+		t.Errorf("extra: expected nil, got %+v", user.Extra)
+	}
+	if !reflect.DeepEqual(user.Meta.Tags, []string{"x", "y"}) {
+		t.Errorf("meta: got %+v", user.Meta)
+	}
+
+	t.Run("invalid json returns error", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "settings"}).AddRow(1, "{oops"))
+		var user testUserWithJSON
+		if _, err := o.Select("SELECT * FROM user").Struct(&user); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestJSONBind(t *testing.T) {
+	registerTestJSON(t)
+	o := &osmBase{dbType: dbTypePostgres, options: &Options{}}
+	user := testUserWithJSON{
+		ID:       1,
+		Settings: testSettings{Theme: "light", Size: 10},
+		Meta:     testRegisteredJSON{Tags: []string{"a"}},
+	}
+	_, params, err := o.readSQLParamsBySQL("test",
+		"UPDATE user SET settings = #{settings}, labels = #{labels}, extra = #{extra}, meta = #{Meta} WHERE id = #{id}", user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{`{"theme":"light","size":10}`, nil, nil, `{"tags":["a"]}`, int64(1)}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("got %#v, want %#v", params, want)
+	}
+}
@@ -0,0 +1,214 @@
+package osm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor Keyset的游标无法解析或签名不匹配
+var ErrInvalidCursor = errors.New("osm: invalid cursor")
+
+// Keyset 游标分页的参数
+type Keyset struct {
+	// Columns 排序的键列，为查询结果中的列名，组合起来应唯一且不为NULL，如[]string{"created_at", "id"}
+	Columns []string
+	// Desc 为true时按键列降序
+	Desc bool
+	// Cursor 上一页返回的游标，为空时查询第一页
+	Cursor string
+	// Limit 每页的行数
+	Limit int
+}
+
+// Keyset 游标分页查询，将游标之后的Limit行读入struct切片container，返回下一页的游标，没有下一页时为空
+//
+// sql作为子查询，按键列加上WHERE (k1, k2) > (#{a}, #{b})、ORDER BY和LIMIT，
+// MSSQL、Oracle不支持行比较，展开为k1 > #{a} OR (k1 = #{a} AND k2 > #{b})。
+// 游标为最后一行键列的值，JSON编码后base64，Options.CursorSecret不为空时附加HMAC签名。
+//
+// 用法:
+//
+//	var users []User
+//	next, err := o.Select(`SELECT id, name, created_at FROM users WHERE status = #{Status}`, 1).
+//		Keyset(osm.Keyset{Columns: []string{"created_at", "id"}, Cursor: cursor, Limit: 20}, &users)
+func (sr *SelectResult) Keyset(ks Keyset, container interface{}) (string, error) {
+	if sr.err != nil {
+		return "", sr.err
+	}
+	if len(ks.Columns) == 0 || ks.Limit <= 0 {
+		return "", fmt.Errorf("sql '%s' error : Keyset需要键列和大于0的Limit", sr.sql)
+	}
+	pointValue := reflect.ValueOf(container)
+	if pointValue.Kind() != reflect.Ptr || pointValue.Elem().Kind() != reflect.Slice {
+		return "", fmt.Errorf("sql '%s' error : Keyset查询结果类型应为struct切片的指针，而您传入的是%T", sr.sql, container)
+	}
+	structType := pointValue.Elem().Type().Elem()
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return "", fmt.Errorf("sql '%s' error : Keyset查询结果类型应为struct切片的指针，而您传入的是%T", sr.sql, container)
+	}
+
+	o := sr.osmBase
+	tagMap := map[string]*structFieldInfo{}
+	nameMap := map[string]*structFieldInfo{}
+	if err := getStructFieldMap(structType, tagMap, nameMap); err != nil {
+		return "", fmt.Errorf("sql '%s' error : %s", sr.sql, err.Error())
+	}
+	fields := make([]*structFieldInfo, len(ks.Columns))
+	for i, col := range ks.Columns {
+		field, err := findFieldBy(o.options.nameMapper(), tagMap, nameMap, col)
+		if err != nil {
+			return "", fmt.Errorf("sql '%s' error : %s", sr.sql, err.Error())
+		}
+		if field == nil {
+			return "", fmt.Errorf("sql '%s' error : %s没有与键列'%s'对应的成员", sr.sql, structType, col)
+		}
+		fields[i] = field
+	}
+
+	sql := "SELECT * FROM (" + trimOrderBy(trimSQL(sr.sql)) + ") osm_keyset"
+	sqlParams := sr.sqlParams
+	if ks.Cursor != "" {
+		values, err := o.decodeCursor(ks.Cursor, fields)
+		if err != nil {
+			return "", err
+		}
+		var where string
+		where, sqlParams = o.keysetWhere(ks, values, sqlParams)
+		sql += " WHERE " + where
+	}
+	orders := make([]string, len(ks.Columns))
+	for i, col := range ks.Columns {
+		orders[i] = o.quoteIdent(col)
+		if ks.Desc {
+			orders[i] += " DESC"
+		}
+	}
+	sql = o.limitSQL(sql+" ORDER BY "+strings.Join(orders, ", "), ks.Limit, 0)
+
+	count, err := resultStructs(sr.logPrefix, o, sql, sql, sqlParams, container)
+	if err != nil || count < int64(ks.Limit) {
+		return "", err
+	}
+	last := reflect.Indirect(pointValue.Elem().Index(pointValue.Elem().Len() - 1))
+	return o.encodeCursor(last, fields)
+}
+
+// keysetWhere 生成游标之后的条件，参数追加到sqlParams之后
+func (o *osmBase) keysetWhere(ks Keyset, values []interface{}, sqlParams []interface{}) (string, []interface{}) {
+	op := " > "
+	if ks.Desc {
+		op = " < "
+	}
+	params := append([]interface{}{}, sqlParams...)
+	bind := func(i int) string {
+		params = append(params, values[i])
+		return o.placeholder(len(params))
+	}
+	columns := make([]string, len(ks.Columns))
+	for i, col := range ks.Columns {
+		columns[i] = o.quoteIdent(col)
+	}
+	if len(columns) == 1 {
+		return columns[0] + op + bind(0), params
+	}
+
+	switch o.dbType {
+	case dbTypeMssql, dbTypeOracle:
+		// 不支持行比较，展开为 k1 > a OR (k1 = a AND k2 > b) ...
+		ors := make([]string, len(columns))
+		for i := range columns {
+			ands := make([]string, 0, i+1)
+			for j := 0; j < i; j++ {
+				ands = append(ands, columns[j]+" = "+bind(j))
+			}
+			ands = append(ands, columns[i]+op+bind(i))
+			ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+		}
+		return "(" + strings.Join(ors, " OR ") + ")", params
+	}
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = bind(i)
+	}
+	return "(" + strings.Join(columns, ", ") + ")" + op + "(" + strings.Join(placeholders, ", ") + ")", params
+}
+
+// cursorSign 游标内容的HMAC-SHA256签名
+func (o *osmBase) cursorSign(payload []byte) string {
+	mac := hmac.New(sha256.New, o.options.CursorSecret)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encodeCursor 将最后一行键列的值编码为游标
+func (o *osmBase) encodeCursor(elem reflect.Value, fields []*structFieldInfo) (string, error) {
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		if v := structFieldValue(elem, field); v.IsValid() {
+			values[i] = v.Interface()
+		}
+	}
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("Keyset error : 游标编码失败 : %s", err.Error())
+	}
+	cursor := base64.RawURLEncoding.EncodeToString(payload)
+	if len(o.options.CursorSecret) > 0 {
+		cursor += "." + o.cursorSign(payload)
+	}
+	return cursor, nil
+}
+
+// decodeCursor 解析游标，按键列成员的类型还原值并转为参数
+func (o *osmBase) decodeCursor(cursor string, fields []*structFieldInfo) ([]interface{}, error) {
+	data, sign, signed := strings.Cut(cursor, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if len(o.options.CursorSecret) > 0 {
+		if !signed || !hmac.Equal([]byte(sign), []byte(o.cursorSign(payload))) {
+			return nil, ErrInvalidCursor
+		}
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(payload, &raws); err != nil || len(raws) != len(fields) {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		v := reflect.New(*field.t)
+		if err := json.Unmarshal(raws[i], v.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		if values[i], err = o.cursorValue(v.Elem()); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// cursorValue 将游标中的值转为参数，时间不按Options.BindTimeAs格式化，保留小数秒和时区，
+// 避免与最后一行同一秒的行被重复返回
+func (o *osmBase) cursorValue(v reflect.Value) (interface{}, error) {
+	switch t := v.Interface().(type) {
+	case time.Time:
+		return t, nil
+	case Null[time.Time]:
+		if !t.Valid {
+			return nil, nil
+		}
+		return t.V, nil
+	}
+	return o.bindValue(v)
+}
@@ -0,0 +1,109 @@
+package osm
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testKeysetRow struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func TestKeyset(t *testing.T) {
+	o, mock := newMockOsm(t)
+	t1 := time.Date(2024, 6, 15, 10, 30, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM (SELECT id, name, created_at FROM user WHERE status = ?) osm_keyset ORDER BY `created_at`, `id` LIMIT 2 OFFSET 0")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}).
+			AddRow(int64(1), "a", t1).
+			AddRow(int64(9007199254740993), "b", t1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM (SELECT id, name, created_at FROM user WHERE status = ?) osm_keyset WHERE (`created_at`, `id`) > (?, ?) ORDER BY `created_at`, `id` LIMIT 2 OFFSET 0")).
+		WithArgs(1, t1, int64(9007199254740993)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}).AddRow(int64(9007199254740994), "c", t1))
+
+	ks := Keyset{Columns: []string{"created_at", "id"}, Limit: 2}
+	var page1 []testKeysetRow
+	next, err := o.Select("SELECT id, name, created_at FROM user WHERE status = #{Status} ORDER BY id", 1).Keyset(ks, &page1)
+	if err != nil || len(page1) != 2 || next == "" {
+		t.Fatalf("next=%q rows=%v err=%v", next, page1, err)
+	}
+
+	ks.Cursor = next
+	var page2 []*testKeysetRow
+	next, err = o.Select("SELECT id, name, created_at FROM user WHERE status = #{Status};", 1).Keyset(ks, &page2)
+	if err != nil || len(page2) != 1 || next != "" {
+		t.Fatalf("next=%q rows=%v err=%v", next, page2, err)
+	}
+}
+
+func TestKeysetWhere(t *testing.T) {
+	ks := Keyset{Columns: []string{"a", "b"}, Desc: true}
+	values := []interface{}{1, 2}
+
+	o := &osmBase{dbType: dbTypeMssql, options: &Options{}}
+	where, params := o.keysetWhere(ks, values, []interface{}{"x"})
+	if want := "(([a] < $2) OR ([a] = $3 AND [b] < $4))"; where != want {
+		t.Errorf("mssql where = %q, want %q", where, want)
+	}
+	if len(params) != 4 || params[0] != "x" || params[1] != 1 || params[2] != 1 || params[3] != 2 {
+		t.Errorf("mssql params = %v", params)
+	}
+
+	o.dbType = dbTypePostgres
+	where, _ = o.keysetWhere(ks, values, []interface{}{"x"})
+	if want := `("a", "b") < ($2, $3)`; where != want {
+		t.Errorf("postgres where = %q, want %q", where, want)
+	}
+}
+
+func TestKeysetCursorSignature(t *testing.T) {
+	o := &osmBase{options: &Options{CursorSecret: []byte("secret")}}
+	tagMap, nameMap := map[string]*structFieldInfo{}, map[string]*structFieldInfo{}
+	getStructFieldMap(reflect.TypeOf(testKeysetRow{}), tagMap, nameMap)
+	fields := []*structFieldInfo{nameMap["ID"]}
+
+	cursor, err := o.encodeCursor(reflect.ValueOf(testKeysetRow{ID: 42}), fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := o.decodeCursor(cursor, fields)
+	if err != nil || len(values) != 1 || values[0] != int64(42) {
+		t.Fatalf("values=%v err=%v", values, err)
+	}
+
+	tampered := []byte(cursor)
+	tampered[0] ^= 1
+	for _, bad := range []string{string(tampered), cursor[:len(cursor)-2], "bm90IGpzb24", "W10"} {
+		if _, err := o.decodeCursor(bad, fields); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeCursor(%q) err = %v, want ErrInvalidCursor", bad, err)
+		}
+	}
+}
+
+func TestKeysetCursorTime(t *testing.T) {
+	// 默认的BindTimeAs下游标中的时间也保留小数秒和时区
+	o := &osmBase{options: &Options{}}
+	tagMap, nameMap := map[string]*structFieldInfo{}, map[string]*structFieldInfo{}
+	getStructFieldMap(reflect.TypeOf(testKeysetRow{}), tagMap, nameMap)
+	fields := []*structFieldInfo{nameMap["CreatedAt"], nameMap["ID"]}
+
+	t1 := time.Date(2024, 6, 15, 10, 30, 0, 123456000, time.FixedZone("CST", 8*3600))
+	cursor, err := o.encodeCursor(reflect.ValueOf(testKeysetRow{ID: 1, CreatedAt: t1}), fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := o.decodeCursor(cursor, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := values[0].(time.Time); !ok || !got.Equal(t1) {
+		t.Errorf("values[0] = %#v, want %v", values[0], t1)
+	}
+}
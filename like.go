@@ -0,0 +1,90 @@
+package osm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// LIKE参数修饰符，写在LIKE之后的参数中，如name LIKE #{q|contains}
+//
+//	contains   %q%
+//	prefix     q%
+//	suffix     %q
+//	icontains、iprefix、isuffix  同上，不区分大小写，PostgreSQL、CockroachDB、ClickHouse改为ILIKE，
+//	                            其他数据库保持LIKE(通常由排序规则决定是否区分大小写)
+//
+// 参数中的%、_按字面匹配：ClickHouse用\转义，其他数据库用!转义并加上ESCAPE '!'，MSSQL还会转义[。
+var likeModifiers = map[string]bool{
+	"contains": true, "prefix": true, "suffix": true,
+	"icontains": true, "iprefix": true, "isuffix": true,
+}
+
+// likeKeyword 校验修饰符和参数前的LIKE，不区分大小写的修饰符按数据库类型将LIKE改为ILIKE
+func (o *osmBase) likeKeyword(sqlText, like string) (string, error) {
+	if !likeModifiers[like] {
+		return sqlText, fmt.Errorf("不支持的修饰符'%s'", like)
+	}
+	text := strings.TrimRight(sqlText, " \t\r\n")
+	upper := strings.ToUpper(text)
+	isLike := func(keyword string) bool {
+		n := len(keyword)
+		return strings.HasSuffix(upper, keyword) && (len(text) == n || !isIdentChar(text[len(text)-n-1]))
+	}
+	if !isLike("LIKE") && !isLike("ILIKE") {
+		return sqlText, fmt.Errorf("修饰符'%s'只能用于LIKE之后", like)
+	}
+	if !strings.HasPrefix(like, "i") || isLike("ILIKE") {
+		return sqlText, nil
+	}
+	switch o.dbType {
+	case dbTypePostgres, dbTypeCockroach, dbTypeClickHouse:
+		return text[:len(text)-4] + "ILIKE" + sqlText[len(text):], nil
+	}
+	return sqlText, nil
+}
+
+// likeEscapeClause 参数之后的ESCAPE子句
+func (o *osmBase) likeEscapeClause() string {
+	if o.dbType == dbTypeClickHouse {
+		return ""
+	}
+	return " ESCAPE '!'"
+}
+
+// likePattern 转义参数中的通配符并按修饰符加上%，参数为nil时绑定NULL
+func (o *osmBase) likePattern(like string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		return nil, fmt.Errorf("LIKE参数应为字符串，而您传入的是%T", value)
+	}
+
+	var replacer *strings.Replacer
+	switch o.dbType {
+	case dbTypeClickHouse:
+		replacer = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	case dbTypeMssql:
+		replacer = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_", "[", "![")
+	default:
+		replacer = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	}
+	pattern := replacer.Replace(v.String())
+	switch strings.TrimPrefix(like, "i") {
+	case "contains":
+		pattern = "%" + pattern + "%"
+	case "prefix":
+		pattern += "%"
+	case "suffix":
+		pattern = "%" + pattern
+	}
+	return pattern, nil
+}
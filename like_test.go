@@ -0,0 +1,91 @@
+package osm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLikeModifiers(t *testing.T) {
+	tests := []struct {
+		dbType     dbType
+		sql        string
+		wantSQL    string
+		wantParams []interface{}
+	}{
+		{
+			dbTypeMysql,
+			"SELECT * FROM user WHERE name LIKE #{Q|contains} OR email NOT LIKE #{Q|prefix}",
+			"SELECT * FROM user WHERE name LIKE ? ESCAPE '!' OR email NOT LIKE ? ESCAPE '!'",
+			[]interface{}{"%50!% off!_!!%", "50!% off!_!!%"},
+		},
+		{
+			dbTypePostgres,
+			"SELECT * FROM user WHERE name like #{Q|icontains} AND email ILIKE #{Q|isuffix}",
+			"SELECT * FROM user WHERE name ILIKE $1 ESCAPE '!' AND email ILIKE $2 ESCAPE '!'",
+			[]interface{}{"%50!% off!_!!%", "%50!% off!_!!"},
+		},
+		{
+			dbTypeMssql,
+			"SELECT * FROM [user] WHERE name LIKE #{Q|suffix}",
+			"SELECT * FROM [user] WHERE name LIKE $1 ESCAPE '!'",
+			[]interface{}{"%50!% off!_!!![a]"},
+		},
+		{
+			dbTypeClickHouse,
+			"SELECT * FROM user WHERE name LIKE #{Q|iprefix}",
+			"SELECT * FROM user WHERE name ILIKE $1",
+			[]interface{}{`50\% off\_!\\[a]%`},
+		},
+	}
+	for _, tc := range tests {
+		o := &osmBase{dbType: tc.dbType, options: &Options{}}
+		q := "50% off_!"
+		if tc.dbType == dbTypeMssql {
+			q = "50% off_![a]"
+		}
+		if tc.dbType == dbTypeClickHouse {
+			q = `50% off_!\[a]`
+		}
+		sql, params, err := o.readSQLParamsBySQL("", tc.sql, map[string]interface{}{"Q": q})
+		if err != nil {
+			t.Fatalf("%d: %v", tc.dbType, err)
+		}
+		if sql != tc.wantSQL {
+			t.Errorf("%d: sql = %q, want %q", tc.dbType, sql, tc.wantSQL)
+		}
+		if len(params) != len(tc.wantParams) {
+			t.Fatalf("%d: params = %v", tc.dbType, params)
+		}
+		for i := range params {
+			if params[i] != tc.wantParams[i] {
+				t.Errorf("%d: params[%d] = %q, want %q", tc.dbType, i, params[i], tc.wantParams[i])
+			}
+		}
+	}
+}
+
+func TestLikeModifierErrors(t *testing.T) {
+	o := &osmBase{dbType: dbTypeMysql, options: &Options{}}
+	tests := []struct {
+		sql   string
+		param interface{}
+		want  string
+	}{
+		{"SELECT * FROM user WHERE name LIKE #{q|upper}", map[string]interface{}{"q": "a"}, "不支持的修饰符"},
+		{"SELECT * FROM user WHERE name = #{q|contains}", map[string]interface{}{"q": "a"}, "只能用于LIKE之后"},
+		{"SELECT * FROM user WHERE dislike #{q|contains}", map[string]interface{}{"q": "a"}, "只能用于LIKE之后"},
+		{"SELECT * FROM user WHERE name LIKE #{q|contains}", map[string]interface{}{"q": 1}, "应为字符串"},
+	}
+	for _, tc := range tests {
+		_, _, err := o.readSQLParamsBySQL("", tc.sql, tc.param)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.sql, err, tc.want)
+		}
+	}
+
+	// NULL参数按NULL绑定
+	_, params, err := o.readSQLParamsBySQL("", "SELECT * FROM user WHERE name LIKE #{q|contains}", map[string]interface{}{"q": nil})
+	if err != nil || len(params) != 1 || params[0] != nil {
+		t.Errorf("params=%v err=%v", params, err)
+	}
+}
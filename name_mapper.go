@@ -0,0 +1,139 @@
+package osm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NameMapper 列名与struct成员名的转换规则，通过Options.NameMapper设置
+//
+// 列名先按db标签匹配，没有标签时再通过NameMapper匹配成员名。
+type NameMapper interface {
+	// ColumnToFields 返回列名可能对应的成员名，按优先级排列
+	ColumnToFields(column string) []string
+	// FieldToColumn 返回成员名对应的列名
+	FieldToColumn(field string) string
+}
+
+// NameFolder 实现了NameFolder的NameMapper，在ColumnToFields没有匹配到成员时，
+// 会比较FoldName处理后的列名与成员名
+type NameFolder interface {
+	FoldName(name string) string
+}
+
+var (
+	// SnakeMapper 默认规则，user_name对应UserName，user_id对应UserID或UserId
+	SnakeMapper NameMapper = NewSnakeMapper()
+	// CamelMapper userName对应UserName，userId对应UserID或UserId
+	CamelMapper NameMapper = camelMapper{SnakeMapper.(snakeMapper)}
+	// ExactMapper 列名与成员名完全相同
+	ExactMapper NameMapper = exactMapper{}
+	// CaseInsensitiveMapper 忽略大小写和下划线，USER_NAME、username、UserName都对应UserName
+	CaseInsensitiveMapper NameMapper = caseInsensitiveMapper{}
+)
+
+// NewSnakeMapper 创建下划线命名规则，initialisms为额外的缩写词，如"SKU"、"OAuth"
+//
+// 如：
+//
+//	o, err := osm.New("mysql", dsn, osm.Options{
+//		NameMapper: osm.NewSnakeMapper("SKU"), // product_sku对应ProductSKU
+//	})
+func NewSnakeMapper(initialisms ...string) NameMapper {
+	m := snakeMapper{initialisms: make(map[string][]byte, len(commonInitialisms)+len(initialisms))}
+	for k, v := range commonInitialisms {
+		m.initialisms[k] = v
+	}
+	for _, word := range initialisms {
+		if word == "" {
+			continue
+		}
+		m.initialisms[strings.ToUpper(word[:1])+strings.ToLower(word[1:])] = []byte(word)
+	}
+	return m
+}
+
+type snakeMapper struct {
+	initialisms map[string][]byte
+}
+
+func (m snakeMapper) ColumnToFields(column string) []string {
+	a, b := toGoNamesWith(column, m.initialisms)
+	if a == b {
+		return []string{a}
+	}
+	return []string{a, b}
+}
+
+func (m snakeMapper) FieldToColumn(field string) string {
+	return toSnakeName(field)
+}
+
+type camelMapper struct {
+	snake snakeMapper
+}
+
+func (m camelMapper) ColumnToFields(column string) []string {
+	return m.snake.ColumnToFields(toSnakeName(column))
+}
+
+func (m camelMapper) FieldToColumn(field string) string {
+	words := strings.Split(toSnakeName(field), "_")
+	for i := 1; i < len(words); i++ {
+		if words[i] != "" {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+type exactMapper struct{}
+
+func (exactMapper) ColumnToFields(column string) []string {
+	return []string{column}
+}
+
+func (exactMapper) FieldToColumn(field string) string {
+	return field
+}
+
+type caseInsensitiveMapper struct{}
+
+func (caseInsensitiveMapper) ColumnToFields(column string) []string {
+	return []string{column}
+}
+
+func (caseInsensitiveMapper) FieldToColumn(field string) string {
+	return field
+}
+
+func (caseInsensitiveMapper) FoldName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// toSnakeName 成员名转为下划线命名，如UserID转为user_id，HTTPServer转为http_server
+func toSnakeName(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' &&
+				(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+					(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// nameMapper 返回当前使用的NameMapper
+func (options *Options) nameMapper() NameMapper {
+	if options.NameMapper == nil {
+		return SnakeMapper
+	}
+	return options.NameMapper
+}
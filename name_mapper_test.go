@@ -0,0 +1,98 @@
+package osm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestToSnakeName(t *testing.T) {
+	tests := map[string]string{
+		"UserName":   "user_name",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"ID":         "id",
+		"Rpc1150":    "rpc1150",
+		"Name":       "name",
+		"userName":   "user_name",
+	}
+	for in, want := range tests {
+		if got := toSnakeName(in); got != want {
+			t.Errorf("toSnakeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNameMappers(t *testing.T) {
+	type product struct {
+		ID         int64
+		UserName   string
+		ProductSKU string
+	}
+	tagMap := map[string]*structFieldInfo{}
+	nameMap := map[string]*structFieldInfo{}
+	getStructFieldMap(reflect.TypeOf(product{}), tagMap, nameMap)
+
+	tests := []struct {
+		name   string
+		mapper NameMapper
+		column string
+		want   string
+	}{
+		{"snake", SnakeMapper, "user_name", "UserName"},
+		{"snake initialism", SnakeMapper, "id", "ID"},
+		{"snake custom initialism", NewSnakeMapper("SKU"), "product_sku", "ProductSKU"},
+		{"snake without custom initialism", SnakeMapper, "product_sku", ""},
+		{"camel", CamelMapper, "userName", "UserName"},
+		{"camel initialism", CamelMapper, "productSku", ""},
+		{"exact", ExactMapper, "UserName", "UserName"},
+		{"exact mismatch", ExactMapper, "user_name", ""},
+		{"case insensitive upper", CaseInsensitiveMapper, "USER_NAME", "UserName"},
+		{"case insensitive lower", CaseInsensitiveMapper, "username", "UserName"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := findFieldBy(tt.mapper, tagMap, nameMap, tt.column)
+			got := ""
+			if f != nil {
+				got = f.n
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := CamelMapper.FieldToColumn("UserID"); got != "userId" {
+		t.Errorf("CamelMapper.FieldToColumn: got %q", got)
+	}
+	if got := SnakeMapper.FieldToColumn("UserID"); got != "user_id" {
+		t.Errorf("SnakeMapper.FieldToColumn: got %q", got)
+	}
+}
+
+func TestOptionsNameMapper(t *testing.T) {
+	type legacyUser struct {
+		ID       int64
+		UserName string
+	}
+	o, mock := newMockOsm(t)
+	o.options.NameMapper = CaseInsensitiveMapper
+	rows := sqlmock.NewRows([]string{"ID", "USER_NAME"}).AddRow(1, "Alice")
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+	var users []legacyUser
+	if _, err := o.Select("SELECT ID, USER_NAME FROM USERS").Structs(&users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].ID != 1 || users[0].UserName != "Alice" {
+		t.Errorf("got %+v", users)
+	}
+
+	opts := &Options{Initialisms: []string{"SKU"}}
+	opts.tidy()
+	if fields := opts.nameMapper().ColumnToFields("product_sku"); fields[len(fields)-1] != "ProductSKU" {
+		t.Errorf("Initialisms: got %v", fields)
+	}
+}
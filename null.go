@@ -0,0 +1,127 @@
+package osm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+)
+
+// NullPolicy 读取NULL到非指针、非Null[T]成员时的处理方式，通过Options.NullPolicy设置
+type NullPolicy int
+
+const (
+	// NullAsZero 默认方式，设为零值
+	NullAsZero NullPolicy = iota
+	// NullAsError 返回错误
+	NullAsError
+	// NullKeepPrevious 保留成员原来的值
+	NullKeepPrevious
+)
+
+// Null 可为NULL的值，Valid为false时表示NULL，可用于结果成员和参数
+//
+// 如：
+//
+//	type User struct {
+//		ID  int64            `db:"id"`
+//		Age osm.Null[int64]  `db:"age"`
+//	}
+//
+//	o.Update("UPDATE user SET age=#{Age} WHERE id=#{ID}", User{ID: 1}) // age设为NULL
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NullOf 返回值为v的Null
+func NullOf[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// Ptr Valid时返回值的指针，否则返回nil
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.V
+	return &v
+}
+
+// nullScanner、nullBinder 由Null[T]实现，使读取和绑定使用osm的转换规则(转换器、时区等)
+type nullScanner interface {
+	scanNull(o *osmBase, logPrefix string, src interface{}) error
+}
+
+type nullBinder interface {
+	bindNull(o *osmBase) (interface{}, error)
+}
+
+var nullScannerType = reflect.TypeOf((*nullScanner)(nil)).Elem()
+
+// defaultNullBase 不通过osm使用Null[T]时(如直接用database/sql)的转换设置
+var defaultNullBase = func() *osmBase {
+	options := &Options{}
+	options.tidy()
+	return &osmBase{options: options}
+}()
+
+func (n *Null[T]) scanNull(o *osmBase, logPrefix string, src interface{}) error {
+	if src == nil {
+		*n = Null[T]{}
+		return nil
+	}
+	dest := reflect.ValueOf(&n.V).Elem()
+	destType := dest.Type()
+	isPtr := destType.Kind() == reflect.Ptr
+	if isPtr {
+		destType = destType.Elem()
+	}
+	if err := o.convertAssign(logPrefix, dest, src, isPtr, destType); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+func (n Null[T]) bindNull(o *osmBase) (interface{}, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return o.bindValue(reflect.ValueOf(&n.V).Elem())
+}
+
+// Scan 实现sql.Scanner
+func (n *Null[T]) Scan(src interface{}) error {
+	return n.scanNull(defaultNullBase, "", src)
+}
+
+// Value 实现driver.Valuer
+func (n Null[T]) Value() (driver.Value, error) {
+	v, err := n.bindNull(defaultNullBase)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+// scanNullValue 读取到Null[T]类型的成员
+func (o *osmBase) scanNullValue(logPrefix string, dest reflect.Value, src interface{}, destIsPtr bool, destType reflect.Type) error {
+	data := reflect.New(destType)
+	if err := data.Interface().(nullScanner).scanNull(o, logPrefix, src); err != nil {
+		return err
+	}
+	setValue(destIsPtr, dest, data.Elem().Interface(), destType)
+	return nil
+}
+
+// assignNull 按NullPolicy处理读取到的NULL，dest为非指针成员
+func (o *osmBase) assignNull(dest reflect.Value, destType reflect.Type) error {
+	switch o.options.NullPolicy {
+	case NullAsError:
+		return fmt.Errorf("cannot scan NULL into non-nullable %s", destType.String())
+	case NullKeepPrevious:
+		return nil
+	}
+	dest.Set(reflect.New(destType).Elem())
+	return nil
+}
@@ -0,0 +1,174 @@
+package osm
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testNullUser struct {
+	ID      int64           `db:"id"`
+	Age     Null[int64]     `db:"age"`
+	Name    Null[string]    `db:"name"`
+	Created Null[time.Time] `db:"created"`
+}
+
+func TestNullScan(t *testing.T) {
+	o, mock := newMockOsm(t)
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "age", "name", "created"}).
+			AddRow(1, nil, []byte("alice"), "2024-06-15 10:30:00").
+			AddRow(2, int64(0), nil, nil),
+	)
+
+	var users []testNullUser
+	if _, err := o.SelectStructs("SELECT * FROM user")(&users); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(users))
+	}
+	if users[0].Age.Valid {
+		t.Errorf("users[0].Age should be NULL, got %v", users[0].Age)
+	}
+	if !users[0].Name.Valid || users[0].Name.V != "alice" {
+		t.Errorf("users[0].Name = %v", users[0].Name)
+	}
+	if !users[0].Created.Valid || users[0].Created.V.Hour() != 10 {
+		t.Errorf("users[0].Created = %v", users[0].Created)
+	}
+	if !users[1].Age.Valid || users[1].Age.V != 0 {
+		t.Errorf("users[1].Age should be valid 0, got %v", users[1].Age)
+	}
+	if users[1].Name.Valid || users[1].Created.Valid {
+		t.Errorf("users[1] Name/Created should be NULL")
+	}
+}
+
+func TestNullScanPointer(t *testing.T) {
+	o, mock := newMockOsm(t)
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"age", "name"}).
+			AddRow(nil, nil).
+			AddRow(int64(0), []byte("")),
+	)
+
+	type row struct {
+		Age  *int64  `db:"age"`
+		Name *string `db:"name"`
+	}
+	var rows []row
+	if _, err := o.SelectStructs("SELECT age, name FROM user")(&rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].Age != nil || rows[0].Name != nil {
+		t.Errorf("NULL should scan into nil pointers, got %v %v", rows[0].Age, rows[0].Name)
+	}
+	if rows[1].Age == nil || *rows[1].Age != 0 || rows[1].Name == nil || *rows[1].Name != "" {
+		t.Errorf("zero values should scan into non-nil pointers, got %v %v", rows[1].Age, rows[1].Name)
+	}
+}
+
+func TestNullScanValues(t *testing.T) {
+	o, mock := newMockOsm(t)
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"age"}).AddRow(int64(3)).AddRow(nil),
+	)
+
+	var ages []Null[int64]
+	if _, err := o.SelectValues("SELECT age FROM user")(&ages); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ages) != 2 || ages[0] != NullOf(int64(3)) || ages[1].Valid {
+		t.Errorf("ages = %v", ages)
+	}
+}
+
+func TestNullBind(t *testing.T) {
+	o, _ := newMockOsm(t)
+	_, params, err := o.readSQLParamsBySQL("test", "UPDATE user SET age=#{Age}, name=#{Name}, created=#{Created} WHERE id=#{ID}",
+		testNullUser{ID: 1, Name: NullOf("bob"), Created: NullOf(time.Date(2024, 6, 15, 10, 30, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 4 {
+		t.Fatalf("expected 4 params, got %d", len(params))
+	}
+	if params[0] != nil {
+		t.Errorf("Age = %#v, want nil", params[0])
+	}
+	if params[1] != "bob" {
+		t.Errorf("Name = %#v, want bob", params[1])
+	}
+	if params[2] != "2024-06-15 10:30:00" {
+		t.Errorf("Created = %#v", params[2])
+	}
+
+	if v, err := NullOf(int32(5)).Value(); err != nil || v != int64(5) {
+		t.Errorf("Value() = %#v, %v", v, err)
+	}
+	if v, err := (Null[string]{}).Value(); err != nil || v != nil {
+		t.Errorf("Value() = %#v, %v", v, err)
+	}
+}
+
+func TestNullPolicy(t *testing.T) {
+	type row struct {
+		ID  int64 `db:"id"`
+		Age int64 `db:"age"`
+	}
+
+	t.Run("zero", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow(1, nil))
+		r := row{Age: 9}
+		if _, err := o.SelectStruct("SELECT id, age FROM user")(&r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Age != 0 {
+			t.Errorf("Age = %d, want 0", r.Age)
+		}
+	})
+
+	t.Run("keep previous", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.options.NullPolicy = NullKeepPrevious
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow(1, nil))
+		r := row{Age: 9}
+		if _, err := o.SelectStruct("SELECT id, age FROM user")(&r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Age != 9 {
+			t.Errorf("Age = %d, want 9", r.Age)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.options.NullPolicy = NullAsError
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow(1, nil))
+		var r row
+		_, err := o.SelectStruct("SELECT id, age FROM user")(&r)
+		if err == nil || !strings.Contains(err.Error(), "NULL") {
+			t.Errorf("expected NULL error, got %v", err)
+		}
+	})
+
+	t.Run("error ignores pointers and Null", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.options.NullPolicy = NullAsError
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "age"}).AddRow(nil, nil))
+		var r struct {
+			ID  *int64      `db:"id"`
+			Age Null[int64] `db:"age"`
+		}
+		if _, err := o.SelectStruct("SELECT id, age FROM user")(&r); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,321 @@
+package osm
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode 小数读取到整数成员时的处理方式，通过Options.NumericRounding设置
+//
+// 数值的读取规则：
+//
+//	目标\来源     整数           浮点数              字符串(DECIMAL等)
+//	int*/uint*   超出范围报错   有小数时按RoundingMode  同浮点数，超出范围报错
+//	float*       直接转换       float32超出范围报错  ParseFloat，超出范围报错
+//	string       十进制文本     最短的十进制文本     原样保留
+//	big.Int      直接转换       有小数时按RoundingMode  同浮点数
+//	big.Rat      直接转换       精确转换            精确解析，保留全部精度
+//	big.Float    直接转换       直接转换            按文本长度确定精度
+type RoundingMode int
+
+const (
+	// RoundError 默认方式，有小数时返回错误
+	RoundError RoundingMode = iota
+	// RoundTruncate 向零截断
+	RoundTruncate
+	// RoundHalfUp 四舍五入(远离零)
+	RoundHalfUp
+	// RoundHalfEven 银行家舍入
+	RoundHalfEven
+)
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func isBigNumberType(t reflect.Type) bool {
+	return t == bigIntType || t == bigRatType || t == bigFloatType
+}
+
+// numberText 取数值来源的十进制文本，ok为false表示来源不是数值
+func numberText(src interface{}) (string, bool) {
+	switch s := src.(type) {
+	case string:
+		return strings.TrimSpace(s), true
+	case []byte:
+		return strings.TrimSpace(string(s)), true
+	case *big.Int:
+		return s.String(), true
+	case *big.Rat:
+		return s.RatString(), true
+	case *big.Float:
+		return s.Text('g', -1), true
+	}
+	rv := reflect.ValueOf(src)
+	if isNumberKind(rv.Kind()) {
+		return asString(src), true
+	}
+	return "", false
+}
+
+// decimalPattern 字符串来源应为十进制文本，可带指数，如-12.50、1e-3，不接受0x1F、10/2等Go的写法
+var decimalPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// decimalText 取得数值来源的十进制文本
+func decimalText(src interface{}, destType interface{}) (string, error) {
+	s, ok := numberText(src)
+	if !ok {
+		return "", fmt.Errorf("converting %T to a %v: unsupported type", src, destType)
+	}
+	if !decimalPattern.MatchString(s) {
+		return "", fmt.Errorf("converting %q to a %v: invalid syntax", s, destType)
+	}
+	return s, nil
+}
+
+// toRat 将数值来源精确转为big.Rat
+func toRat(src interface{}) (*big.Rat, error) {
+	rv := reflect.ValueOf(src)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("converting %v to a number: not a finite value", f)
+		}
+		return new(big.Rat).SetFloat64(f), nil
+	}
+	if r, ok := src.(*big.Rat); ok {
+		return new(big.Rat).Set(r), nil
+	}
+	s, err := decimalText(src, "number")
+	if err != nil {
+		return nil, err
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("converting %q to a number: invalid syntax", s)
+	}
+	return r, nil
+}
+
+// roundRat 按RoundingMode将r转为整数
+func (options *Options) roundRat(r *big.Rat) (*big.Int, error) {
+	if r.IsInt() {
+		return new(big.Int).Set(r.Num()), nil
+	}
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	switch options.NumericRounding {
+	case RoundTruncate:
+		return q, nil
+	case RoundHalfUp, RoundHalfEven:
+		// 比较2*|余数|与分母
+		cmp := new(big.Int).Abs(m)
+		cmp.Lsh(cmp, 1)
+		c := cmp.Cmp(r.Denom())
+		if c > 0 || (c == 0 && (options.NumericRounding == RoundHalfUp || q.Bit(0) == 1)) {
+			if r.Sign() < 0 {
+				q.Sub(q, big.NewInt(1))
+			} else {
+				q.Add(q, big.NewInt(1))
+			}
+		}
+		return q, nil
+	}
+	return nil, fmt.Errorf("converting %s to an integer: fractional part would be lost", r.FloatString(10))
+}
+
+// convertNumber 读取到整数或浮点数成员，超出范围或丢失小数时返回错误
+func (o *osmBase) convertNumber(logPrefix string, dest reflect.Value, src interface{}, destIsPtr bool, destType reflect.Type) error {
+	var err error
+	switch destType.Kind() {
+	case reflect.Float32, reflect.Float64:
+		var f64 float64
+		rv := reflect.ValueOf(src)
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			f64 = rv.Float()
+			if destType.Kind() == reflect.Float32 && !math.IsInf(f64, 0) && math.Abs(f64) > math.MaxFloat32 {
+				err = fmt.Errorf("converting %v to a %s: value out of range", f64, destType)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f64 = float64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f64 = float64(rv.Uint())
+		default:
+			var s string
+			if s, err = decimalText(src, destType); err != nil {
+				break
+			}
+			f64, err = strconv.ParseFloat(s, destType.Bits())
+		}
+		if err != nil {
+			o.options.WarnLogger.Log(logPrefix+"convertAssign Float error", map[string]string{"error": err.Error()})
+			return err
+		}
+		setNumber(destIsPtr, dest, destType, func(v reflect.Value) { v.SetFloat(f64) })
+		return nil
+	}
+
+	var i *big.Int
+	r, err := toRat(src)
+	if err == nil {
+		i, err = o.options.roundRat(r)
+	}
+	if err == nil {
+		switch destType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if !i.IsInt64() || reflect.New(destType).Elem().OverflowInt(i.Int64()) {
+				err = fmt.Errorf("converting %s to a %s: value out of range", i, destType)
+				break
+			}
+			i64 := i.Int64()
+			setNumber(destIsPtr, dest, destType, func(v reflect.Value) { v.SetInt(i64) })
+			return nil
+		default:
+			if !i.IsUint64() || reflect.New(destType).Elem().OverflowUint(i.Uint64()) {
+				err = fmt.Errorf("converting %s to a %s: value out of range", i, destType)
+				break
+			}
+			u64 := i.Uint64()
+			setNumber(destIsPtr, dest, destType, func(v reflect.Value) { v.SetUint(u64) })
+			return nil
+		}
+	}
+	if destType.Kind() >= reflect.Uint && destType.Kind() <= reflect.Uint64 {
+		o.options.WarnLogger.Log(logPrefix+"convertAssign Uint error", map[string]string{"error": err.Error()})
+	} else {
+		o.options.WarnLogger.Log(logPrefix+"convertAssign Int error", map[string]string{"error": err.Error()})
+	}
+	return err
+}
+
+// convertBigNumber 读取到big.Int、big.Rat、big.Float成员
+func (o *osmBase) convertBigNumber(logPrefix string, dest reflect.Value, src interface{}, destIsPtr bool, destType reflect.Type) error {
+	var value reflect.Value
+	switch destType {
+	case bigFloatType:
+		s, err := decimalText(src, destType)
+		if err != nil {
+			return err
+		}
+		prec := uint(len(s))*4 + 64 // 保证文本中的有效数字不丢失
+		f, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+		if err != nil {
+			return fmt.Errorf("converting %q to a %s: %s", s, destType, err.Error())
+		}
+		value = reflect.ValueOf(f)
+	default:
+		r, err := toRat(src)
+		if err != nil {
+			return err
+		}
+		if destType == bigRatType {
+			value = reflect.ValueOf(r)
+			break
+		}
+		i, err := o.options.roundRat(r)
+		if err != nil {
+			o.options.WarnLogger.Log(logPrefix+"convertAssign Int error", map[string]string{"error": err.Error()})
+			return err
+		}
+		value = reflect.ValueOf(i)
+	}
+	if destIsPtr {
+		dest.Set(value)
+	} else {
+		dest.Set(value.Elem())
+	}
+	return nil
+}
+
+// bindBigNumber 将big.Int、big.Rat、big.Float参数转为十进制字符串，big.Rat不能精确表示为有限小数时返回错误
+func bindBigNumber(v interface{}) (interface{}, bool, error) {
+	switch n := v.(type) {
+	case big.Int:
+		return n.String(), true, nil
+	case *big.Int:
+		if n == nil {
+			return nil, true, nil
+		}
+		return n.String(), true, nil
+	case big.Float:
+		return n.Text('f', -1), true, nil
+	case *big.Float:
+		if n == nil {
+			return nil, true, nil
+		}
+		return n.Text('f', -1), true, nil
+	case big.Rat:
+		s, err := ratDecimal(&n)
+		return s, true, err
+	case *big.Rat:
+		if n == nil {
+			return nil, true, nil
+		}
+		s, err := ratDecimal(n)
+		return s, true, err
+	}
+	return nil, false, nil
+}
+
+// ratDecimal 将r精确转为十进制小数文本
+func ratDecimal(r *big.Rat) (string, error) {
+	if r.IsInt() {
+		return r.Num().String(), nil
+	}
+	// 分母只含因子2和5时可以精确表示，所需小数位数为两者指数的较大值
+	d := new(big.Int).Set(r.Denom())
+	zero := big.NewInt(0)
+	m := new(big.Int)
+	digits := 0
+	for _, f := range []int64{2, 5} {
+		factor := big.NewInt(f)
+		n := 0
+		for {
+			q, rem := new(big.Int).QuoRem(d, factor, m)
+			if rem.Cmp(zero) != 0 {
+				break
+			}
+			d = q
+			n++
+		}
+		if n > digits {
+			digits = n
+		}
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return "", fmt.Errorf("converting %s to a decimal: not a terminating decimal", r.RatString())
+	}
+	return r.FloatString(digits), nil
+}
+
+func setNumber(destIsPtr bool, dest reflect.Value, destType reflect.Type, set func(v reflect.Value)) {
+	if destIsPtr {
+		data := reflect.New(destType)
+		set(data.Elem())
+		dest.Set(data)
+	} else {
+		set(dest)
+	}
+}
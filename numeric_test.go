@@ -0,0 +1,129 @@
+package osm
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestConvertNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		rounding RoundingMode
+		src      interface{}
+		dest     interface{}
+		want     interface{}
+		wantErr  string
+	}{
+		{"decimal integral", RoundError, []byte("12.00"), int64(0), int64(12), ""},
+		{"decimal fraction errors", RoundError, []byte("12.50"), int64(0), nil, "fractional"},
+		{"float fraction errors", RoundError, 3.7, int(0), nil, "fractional"},
+		{"truncate", RoundTruncate, []byte("-12.9"), int64(0), int64(-12), ""},
+		{"half up", RoundHalfUp, []byte("12.5"), int64(0), int64(13), ""},
+		{"half up negative", RoundHalfUp, "-12.5", int64(0), int64(-13), ""},
+		{"half even down", RoundHalfEven, "12.5", int64(0), int64(12), ""},
+		{"half even up", RoundHalfEven, 13.5, int64(0), int64(14), ""},
+		{"int8 overflow", RoundError, int64(300), int8(0), nil, "out of range"},
+		{"uint64 beyond int64", RoundError, uint64(1) << 63, int64(0), nil, "out of range"},
+		{"uint64 max", RoundError, []byte("18446744073709551615"), uint64(0), uint64(18446744073709551615), ""},
+		{"negative to uint", RoundError, int64(-1), uint32(0), nil, "out of range"},
+		{"exponent", RoundError, "1e3", int32(0), int32(1000), ""},
+		{"float32 overflow", RoundError, 1e300, float32(0), nil, "out of range"},
+		{"decimal to float", RoundError, []byte("12.50"), float64(0), 12.5, ""},
+		{"int to float", RoundError, int64(7), float32(0), float32(7), ""},
+		{"invalid", RoundError, "abc", int64(0), nil, "invalid syntax"},
+		{"hex text rejected", RoundError, []byte("0x1F"), int64(0), nil, "invalid syntax"},
+		{"fraction text rejected", RoundError, "10/2", int64(0), nil, "invalid syntax"},
+		{"hex float text rejected", RoundError, "0x1p4", float64(0), nil, "invalid syntax"},
+		{"leading dot", RoundError, []byte(".5"), float64(0), 0.5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, _ := newMockOsm(t)
+			o.options.NumericRounding = tt.rounding
+			destType := reflect.TypeOf(tt.dest)
+			dest := reflect.New(destType).Elem()
+			err := o.convertAssign("test", dest, tt.src, false, destType)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := dest.Interface(); got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertNumberPtr(t *testing.T) {
+	o, _ := newMockOsm(t)
+	intType := reflect.TypeOf(int64(0))
+	dest := reflect.New(reflect.PointerTo(intType)).Elem()
+	if err := o.convertAssign("test", dest, []byte("42"), true, intType); err != nil {
+		t.Fatal(err)
+	}
+	if dest.IsNil() || dest.Elem().Int() != 42 {
+		t.Errorf("got %v", dest)
+	}
+}
+
+func TestScanBigNumbers(t *testing.T) {
+	o, mock := newMockOsm(t)
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"amount", "rate", "total", "text"}).
+			AddRow([]byte("12345678901234567890.123456789"), []byte("0.000000000000000000001"), []byte("98765432109876543210"), []byte("12.50")),
+	)
+
+	var row struct {
+		Amount big.Rat    `db:"amount"`
+		Rate   *big.Float `db:"rate"`
+		Total  *big.Int   `db:"total"`
+		Text   string     `db:"text"`
+	}
+	if _, err := o.SelectStruct("SELECT amount, rate, total, text FROM t")(&row); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := row.Amount.FloatString(9); got != "12345678901234567890.123456789" {
+		t.Errorf("Amount = %s", got)
+	}
+	if row.Rate == nil || row.Rate.Text('g', -1) != "1e-21" {
+		t.Errorf("Rate = %v", row.Rate)
+	}
+	if row.Total == nil || row.Total.String() != "98765432109876543210" {
+		t.Errorf("Total = %v", row.Total)
+	}
+	if row.Text != "12.50" {
+		t.Errorf("Text = %q, want 12.50", row.Text)
+	}
+}
+
+func TestBindBigNumbers(t *testing.T) {
+	o, _ := newMockOsm(t)
+	amount, _ := new(big.Rat).SetString("12.5")
+	total, _ := new(big.Int).SetString("98765432109876543210", 10)
+	_, params, err := o.readSQLParamsBySQL("test", "INSERT INTO t VALUES (#{Amount}, #{Total})", map[string]interface{}{
+		"Amount": amount,
+		"Total":  total,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(params, []interface{}{"12.5", "98765432109876543210"}) {
+		t.Errorf("params = %#v", params)
+	}
+
+	_, _, err = o.readSQLParamsBySQL("test", "INSERT INTO t VALUES (#{Amount})", map[string]interface{}{
+		"Amount": big.NewRat(1, 3),
+	})
+	if err == nil || !strings.Contains(err.Error(), "terminating") {
+		t.Errorf("expected non-terminating decimal error, got %v", err)
+	}
+}
@@ -28,16 +28,18 @@ const (
 )
 
 type dbRunner interface {
-	Prepare(query string) (*sql.Stmt, error)
-	Exec(query string, args ...interface{}) (sql.Result, error)
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	QueryRow(query string, args ...interface{}) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
 
 type osmBase struct {
 	db      dbRunner
 	dbType  dbType
 	options *Options
+
+	unscoped bool            // 为true时InsertStruct等方法不处理软删除，见Unscoped()
+	callCtx  context.Context // WithContext()设置的context，用于执行sql和取得autoActor的值
 }
 
 // Osm 对象，通过Struct、Map、Array、value等对象以及Sql Map来操作数据库。可以开启事务。
@@ -45,6 +47,8 @@ type Osm struct {
 	osmBase
 	ctx    context.Context
 	cancel context.CancelFunc
+	// derived 由WithContext、Unscoped返回，与原Osm共用连接，不能Close
+	derived bool
 }
 
 // Tx 与Osm对象一样，不过是在事务中进行操作
@@ -72,9 +76,47 @@ type Options struct {
 	// SQLReplacements SQL替换映射，用于替换SQL中的占位符，如 {"[TablePrefix]": "data_"}
 	// 在SQL执行前会替换所有匹配的占位符
 	SQLReplacements map[string]string
+	// NameMapper 列名与struct成员名的转换规则，默认为SnakeMapper
+	NameMapper NameMapper
+	// Initialisms 额外的缩写词，如[]string{"SKU"}，只在NameMapper为空时用于默认的SnakeMapper；
+	// 设置了NameMapper时被忽略，此时应使用NewSnakeMapper(initialisms...)作为NameMapper
+	Initialisms []string
+	// MapColumnTypes Map/Maps根据列类型元数据转换数值列，整数为int64，浮点数为float64，DECIMAL等为字符串
+	MapColumnTypes bool
+	// TimeLocation 解析不带时区的时间和读取time.Time时使用的时区，默认为time.Local；
+	// 设置后格式化绑定的时间也先转到该时区
+	TimeLocation *time.Location
+	// BindTimeAs time.Time参数的绑定方式，默认为BindTimeDefault
+	BindTimeAs TimeBindMode
+	// TimeFormat 时间格式化为字符串绑定时使用的格式，默认为"2006-01-02 15:04:05"
+	TimeFormat string
+	// TimeLayouts 解析时间字符串时优先尝试的格式
+	TimeLayouts []string
+	// NullPolicy 读取NULL到非指针成员时的处理方式，默认为NullAsZero；指针成员和Null[T]不受影响
+	NullPolicy NullPolicy
+	// NumericRounding 带小数的值读取到整数成员时的处理方式，默认为RoundError
+	NumericRounding RoundingMode
+	// StrictEnums 通过UnmarshalText读取时，UnmarshalText失败或MarshalText不能还原原值时返回错误，
+	// 默认只记录Warn日志并保留零值
+	StrictEnums bool
+	// BatchSize 批量写入(如Upsert)时每条sql的最大行数，默认为500，还会受数据库参数数量上限的限制
+	BatchSize int
+	// MysqlRowAlias 为true时Upsert在MySQL中使用行别名(INSERT ... AS osm_new ... col = osm_new.col)，
+	// 代替MySQL 8.0.20起不推荐的VALUES(col)。需要MySQL 8.0.19及以上，MariaDB和TiDB不支持，默认为false
+	MysqlRowAlias bool
+	// ConcurrentPageCount Page在事务外时并发执行COUNT和数据查询，默认先执行COUNT，总数为0时不再查询数据
+	ConcurrentPageCount bool
+	// CursorSecret 不为空时Keyset返回的游标用HMAC-SHA256签名，解析时校验签名
+	CursorSecret []byte
+	// Clock autoCreateTime、autoUpdateTime和软删除使用的当前时间，默认为time.Now，测试时可以使用固定的时间
+	Clock func() time.Time
+	// ActorFromContext 从WithContext()设置的context中取得操作人，用于autoActor的成员，返回nil时不填入
+	ActorFromContext func(ctx context.Context) interface{}
 
 	// replacer 预编译的字符串替换器，用于提高SQL替换性能
 	replacer *strings.Replacer
+	// converters 通过Osm.RegisterConverter注册的转换器
+	converters *converterRegistry
 }
 
 func (options *Options) tidy() {
@@ -88,6 +130,14 @@ func (options *Options) tidy() {
 		options.InfoLogger = &DefaultLogger{}
 	}
 
+	if options.NameMapper == nil && len(options.Initialisms) > 0 {
+		options.NameMapper = NewSnakeMapper(options.Initialisms...)
+	}
+
+	if options.converters == nil {
+		options.converters = &converterRegistry{}
+	}
+
 	if options.SlowLogDuration == 0 {
 		options.SlowLogDuration = 500 * time.Millisecond
 	}
@@ -237,6 +287,8 @@ func (o *Osm) Begin() (*Tx, error) {
 	tx := new(Tx)
 	tx.dbType = o.dbType
 	tx.options = o.options
+	tx.unscoped = o.unscoped
+	tx.callCtx = o.callCtx
 
 	if o.db == nil {
 		return nil, fmt.Errorf("db no opened")
@@ -247,7 +299,7 @@ func (o *Osm) Begin() (*Tx, error) {
 	}
 
 	var err error
-	tx.db, err = sqlDb.Begin()
+	tx.db, err = sqlDb.BeginTx(o.context(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -306,10 +358,15 @@ func (o *Osm) Transaction(fn func(tx *Tx) error) (err error) {
 
 // Close 与数据库断开连接，释放连接资源
 //
+// 只能关闭New返回的Osm，WithContext、Unscoped返回的Osm与其共用连接，调用Close返回错误。
+//
 // 如：
 //
 //	err := o.Close()
 func (o *Osm) Close() error {
+	if o.derived {
+		return fmt.Errorf("WithContext、Unscoped返回的Osm不能Close，请关闭原Osm")
+	}
 	if o.db == nil {
 		return fmt.Errorf("db not opened")
 	}
@@ -365,35 +422,86 @@ type sqlFragment struct {
 	paramValues []interface{}
 	isParam     bool
 	isIn        bool
+	isArray     bool   // 参数在ANY(、ALL(之后，切片参数整体作为数组绑定
+	isJSON      bool   // 参数对应的成员带json选项
+	like        string // LIKE修饰符，如#{q|contains}中的contains
 }
 
-func setDataToParamName(paramName *sqlFragment, v reflect.Value) {
+func (o *osmBase) setDataToParamName(paramName *sqlFragment, v reflect.Value) error {
+	jv := v
+	if jv.Kind() == reflect.Interface && !jv.IsNil() {
+		jv = jv.Elem()
+	}
+	if !paramName.isIn && (paramName.isJSON || isJSONType(jv.Type())) {
+		value, err := bindJSON(jv)
+		if err != nil {
+			return fmt.Errorf("param '%s' error : %s", paramName.content, err.Error())
+		}
+		paramName.paramValue = value
+		return nil
+	}
 	if paramName.isIn {
 		v = reflect.ValueOf(v.Interface())
 		kind := v.Kind()
 		if kind == reflect.Array || kind == reflect.Slice {
 			for j := 0; j < v.Len(); j++ {
-				vv := v.Index(j)
-				if vv.Type().String() == "time.Time" {
-					paramName.paramValues = append(paramName.paramValues, timeFormat(vv.Interface().(time.Time), formatDateTime))
-				} else {
-					paramName.paramValues = append(paramName.paramValues, vv.Interface())
+				value, err := o.bindValue(v.Index(j))
+				if err != nil {
+					return fmt.Errorf("param '%s' error : %s", paramName.content, err.Error())
 				}
+				paramName.paramValues = append(paramName.paramValues, value)
 			}
 		} else {
-			if v.Type().String() == "time.Time" {
-				paramName.paramValues = append(paramName.paramValues, timeFormat(v.Interface().(time.Time), formatDateTime))
-			} else {
-				paramName.paramValues = append(paramName.paramValues, v.Interface())
+			value, err := o.bindValue(v)
+			if err != nil {
+				return fmt.Errorf("param '%s' error : %s", paramName.content, err.Error())
 			}
+			paramName.paramValues = append(paramName.paramValues, value)
 		}
 	} else {
-		if v.Type().String() == "time.Time" {
-			paramName.paramValue = timeFormat(v.Interface().(time.Time), formatDateTime)
-		} else {
-			paramName.paramValue = v.Interface()
+		value, err := o.bindValue(v)
+		if err != nil {
+			return fmt.Errorf("param '%s' error : %s", paramName.content, err.Error())
+		}
+		paramName.paramValue = value
+	}
+	return nil
+}
+
+// bindValue 将一个参数值转为传给driver的值
+func (o *osmBase) bindValue(v reflect.Value) (interface{}, error) {
+	if value, ok, err := o.bindConverted(v); ok {
+		return value, err
+	}
+	if nb, ok := v.Interface().(nullBinder); ok {
+		return nb.bindNull(o)
+	}
+	if value, ok, err := bindBigNumber(v.Interface()); ok {
+		return value, err
+	}
+	if o.isArrayDB() {
+		av := v
+		if av.Kind() == reflect.Interface && !av.IsNil() {
+			av = av.Elem()
 		}
+		if isArrayParam(av) {
+			return o.encodePGArray(av)
+		}
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return o.options.bindTime(t, v.Kind() == reflect.Interface), nil
+	}
+	if t, ok := v.Interface().(*time.Time); ok {
+		// *time.Time原样交给driver，与map中的time.Time相同
+		if t == nil {
+			return nil, nil
+		}
+		return o.options.bindTime(*t, true), nil
+	}
+	if value, ok, err := bindText(v); ok {
+		return value, err
 	}
+	return v.Interface(), nil
 }
 
 func sqlIsIn(lastSQLText string) bool {
@@ -422,6 +530,26 @@ func sqlIsIn(lastSQLText string) bool {
 	return strings.EqualFold(lastSQLText[start:end], "IN")
 }
 
+// sqlIsArray 判断参数是否紧跟在ANY(或ALL(之后，如 id = ANY(#{ids})
+func sqlIsArray(lastSQLText string) bool {
+	lastSQLText = strings.TrimSpace(lastSQLText)
+	if !strings.HasSuffix(lastSQLText, "(") {
+		return false
+	}
+	lastSQLText = strings.TrimSpace(strings.TrimSuffix(lastSQLText, "("))
+	start := len(lastSQLText)
+	for start > 0 {
+		c := lastSQLText[start-1]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			start--
+			continue
+		}
+		break
+	}
+	word := strings.ToUpper(lastSQLText[start:])
+	return word == "ANY" || word == "ALL"
+}
+
 // getCallerInfo 获取调用者信息，用于日志记录
 func getCallerInfo(skip int) string {
 	_, file, lineNo, ok := runtime.Caller(skip)
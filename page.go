@@ -0,0 +1,84 @@
+package osm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Page 分页查询，将第page页(从1开始)的数据读入struct切片container，返回总行数
+//
+// 总数通过将sql包装为子查询执行COUNT(*)取得(末尾的ORDER BY会被去掉)，
+// 分页按数据库类型使用LIMIT ... OFFSET、OFFSET ... FETCH NEXT(MSSQL、Oracle)或TOP(MSSQL)。
+// sql中不应包含分页语句(LIMIT、OFFSET、FETCH)，否则返回错误；container的struct不应有带prefix选项的成员，
+// 因为分页作用于JOIN后的行而不是合并后的对象，否则也返回错误。
+// Options.ConcurrentPageCount为true且不在事务中时，两个查询并发执行。
+//
+// 用法:
+//
+//	var users []User
+//	total, err := o.Select(`SELECT * FROM users WHERE age > #{Age} ORDER BY id`, 18).Page(2, 20, &users)
+func (sr *SelectResult) Page(page, size int, container interface{}) (int64, error) {
+	if sr.err != nil {
+		return 0, sr.err
+	}
+	if size <= 0 {
+		return 0, fmt.Errorf("sql '%s' error : 分页大小应大于0，而您传入的是%d", sr.sql, size)
+	}
+	if page < 1 {
+		page = 1
+	}
+	for _, keyword := range []string{"LIMIT", "OFFSET", "FETCH"} {
+		if topLevelKeyword(sr.sql, keyword) >= 0 {
+			return 0, fmt.Errorf("sql '%s' error : 分页查询的sql中不应包含%s", sr.sql, keyword)
+		}
+	}
+	if t := reflect.TypeOf(container); t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Slice {
+		structType := t.Elem().Elem()
+		if structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
+		}
+		if structType.Kind() == reflect.Struct && hasNestedFields(structType) {
+			return 0, fmt.Errorf("sql '%s' error : 分页查询不支持带prefix选项的成员", sr.sql)
+		}
+	}
+	o := sr.osmBase
+	offset := (page - 1) * size
+	countQuery := countSQL(sr.sql)
+	dataQuery := o.limitSQL(sr.sql, size, offset)
+
+	var total int64
+	count := func() error {
+		_, err := resultValue(sr.logPrefix, o, countQuery, countQuery, sr.sqlParams, []interface{}{&total})
+		return err
+	}
+	fetch := func() error {
+		_, err := resultStructs(sr.logPrefix, o, dataQuery, dataQuery, sr.sqlParams, container)
+		return err
+	}
+
+	if _, ok := o.db.(*sql.DB); ok && o.options.ConcurrentPageCount {
+		var countErr error
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			countErr = count()
+		}()
+		fetchErr := fetch()
+		wg.Wait()
+		if countErr != nil {
+			return 0, countErr
+		}
+		return total, fetchErr
+	}
+
+	if err := count(); err != nil {
+		return 0, err
+	}
+	if int64(offset) >= total {
+		return total, nil
+	}
+	return total, fetch()
+}
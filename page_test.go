@@ -0,0 +1,132 @@
+package osm
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLimitSQL(t *testing.T) {
+	tests := []struct {
+		dbType dbType
+		sql    string
+		offset int
+		want   string
+	}{
+		{dbTypeMysql, "SELECT * FROM t ORDER BY id;", 20, "SELECT * FROM t ORDER BY id LIMIT 10 OFFSET 20"},
+		{dbTypePostgres, "SELECT * FROM t", 0, "SELECT * FROM t LIMIT 10 OFFSET 0"},
+		{dbTypeOracle, "SELECT * FROM t ORDER BY id", 20, "SELECT * FROM t ORDER BY id OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{dbTypeMssql, "SELECT * FROM t ORDER BY id", 20, "SELECT * FROM t ORDER BY id OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{dbTypeMssql, "select distinct name FROM t", 0, "select distinct TOP 10 name FROM t"},
+		{dbTypeMssql, "SELECT * FROM t", 20, "SELECT * FROM t ORDER BY (SELECT NULL) OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+		// 子查询中的ORDER BY不影响判断
+		{dbTypeMssql, "SELECT * FROM (SELECT TOP 5 * FROM t ORDER BY id) a", 0, "SELECT TOP 10 * FROM (SELECT TOP 5 * FROM t ORDER BY id) a"},
+	}
+	for _, tc := range tests {
+		o := &osmBase{dbType: tc.dbType, options: &Options{}}
+		if got := o.limitSQL(tc.sql, 10, tc.offset); got != tc.want {
+			t.Errorf("limitSQL(%d, %q) = %q, want %q", tc.dbType, tc.sql, got, tc.want)
+		}
+	}
+}
+
+func TestCountSQL(t *testing.T) {
+	tests := map[string]string{
+		"SELECT * FROM t ORDER BY id DESC":                      "SELECT COUNT(*) FROM (SELECT * FROM t) osm_count",
+		"SELECT * FROM t WHERE name = 'x order by y'":           "SELECT COUNT(*) FROM (SELECT * FROM t WHERE name = 'x order by y') osm_count",
+		"SELECT a.* FROM (SELECT * FROM t ORDER BY id) a":       "SELECT COUNT(*) FROM (SELECT a.* FROM (SELECT * FROM t ORDER BY id) a) osm_count",
+		"SELECT * FROM t WHERE reorder_by = 1 ORDER BY created": "SELECT COUNT(*) FROM (SELECT * FROM t WHERE reorder_by = 1) osm_count",
+		"SELECT * FROM t WHERE id > 1 ORDER BY id; ":            "SELECT COUNT(*) FROM (SELECT * FROM t WHERE id > 1) osm_count",
+		// 注释中的ORDER BY不影响判断，末行的--注释后换行
+		"SELECT * FROM t /* ORDER BY x */ WHERE id > 1": "SELECT COUNT(*) FROM (SELECT * FROM t /* ORDER BY x */ WHERE id > 1) osm_count",
+		"SELECT * FROM t -- note ORDER BY x":            "SELECT COUNT(*) FROM (SELECT * FROM t -- note ORDER BY x\n) osm_count",
+		"SELECT * FROM t -- note\nORDER BY id -- by id": "SELECT COUNT(*) FROM (SELECT * FROM t -- note\n) osm_count",
+	}
+	for sql, want := range tests {
+		if got := countSQL(sql); got != want {
+			t.Errorf("countSQL(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	type user struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	t.Run("count then fetch", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM (SELECT id, name FROM user WHERE age > ?) osm_count")).
+			WithArgs(18).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(12)))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name FROM user WHERE age > ? ORDER BY id LIMIT 5 OFFSET 10")).
+			WithArgs(18).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(int64(11), "k").AddRow(int64(12), "l"))
+
+		var users []user
+		total, err := o.Select("SELECT id, name FROM user WHERE age > #{Age} ORDER BY id", 18).Page(3, 5, &users)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 12 || len(users) != 2 || users[0].ID != 11 {
+			t.Errorf("total=%d users=%+v", total, users)
+		}
+	})
+
+	t.Run("skips fetch past the end", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(0)))
+
+		var users []user
+		total, err := o.Select("SELECT id, name FROM user").Page(1, 5, &users)
+		if err != nil || total != 0 || len(users) != 0 {
+			t.Fatalf("total=%d users=%v err=%v", total, users, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.options.ConcurrentPageCount = true
+		mock.MatchExpectationsInOrder(false)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(1)))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name FROM user LIMIT 5 OFFSET 0")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a"))
+
+		var users []user
+		total, err := o.Select("SELECT id, name FROM user").Page(0, 5, &users)
+		if err != nil || total != 1 || len(users) != 1 {
+			t.Fatalf("total=%d users=%v err=%v", total, users, err)
+		}
+	})
+
+	t.Run("rejects limit and nested structs", func(t *testing.T) {
+		o, _ := newMockOsm(t)
+		var users []user
+		if _, err := o.Select("SELECT id FROM user ORDER BY id LIMIT 10").Page(1, 5, &users); err == nil {
+			t.Error("expected error for sql with LIMIT")
+		}
+		type order struct {
+			ID   int64 `db:"id"`
+			User user  `db:"user,prefix=u_"`
+		}
+		var orders []*order
+		if _, err := o.Select("SELECT o.id, u.id AS u_id FROM orders o JOIN user u ON u.id = o.user_id").Page(1, 5, &orders); err == nil {
+			t.Error("expected error for nested struct")
+		}
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		o, _ := newMockOsm(t)
+		var users []user
+		if _, err := o.Select("SELECT id FROM user").Page(1, 0, &users); err == nil {
+			t.Error("expected error for size 0")
+		}
+	})
+}
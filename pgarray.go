@@ -0,0 +1,193 @@
+package osm
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// isArrayDB 数据库是否支持数组类型(PostgreSQL、CockroachDB)
+func (o *osmBase) isArrayDB() bool {
+	return o.dbType == dbTypePostgres || o.dbType == dbTypeCockroach
+}
+
+// isArrayParam 参数是否应按数组绑定，[]byte按二进制处理
+func isArrayParam(v reflect.Value) bool {
+	kind := v.Kind()
+	if kind != reflect.Slice && kind != reflect.Array {
+		return false
+	}
+	return v.Type().Elem().Kind() != reflect.Uint8
+}
+
+// encodePGArray 将切片编码为数组字面量，如[]int64{1, 2}为{1,2}，[]string{"a"}为{"a"}，nil切片为NULL
+func (o *osmBase) encodePGArray(v reflect.Value) (interface{}, error) {
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return nil, nil
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		ev := v.Index(i)
+		if ev.Kind() == reflect.Interface || ev.Kind() == reflect.Ptr {
+			if ev.IsNil() {
+				sb.WriteString("NULL")
+				continue
+			}
+			ev = ev.Elem()
+		}
+		value, err := o.bindValue(ev)
+		if err != nil {
+			return nil, err
+		}
+		switch e := value.(type) {
+		case nil:
+			sb.WriteString("NULL")
+		case string:
+			writePGArrayString(&sb, e)
+		case []byte:
+			writePGArrayString(&sb, string(e))
+		case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			sb.WriteString(asString(e))
+		default:
+			writePGArrayString(&sb, fmt.Sprintf("%v", e))
+		}
+	}
+	sb.WriteByte('}')
+	return sb.String(), nil
+}
+
+func writePGArrayString(sb *strings.Builder, s string) {
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	sb.WriteByte('"')
+}
+
+// isPGArrayDest 目标类型是否可以接收数组字面量
+func isPGArrayDest(destType reflect.Type) bool {
+	if destType.Kind() != reflect.Slice {
+		return false
+	}
+	switch destType.Elem().Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// parsePGArray 解析一维数组字面量，NULL元素为nil
+func parsePGArray(s string) ([]*string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("invalid array literal %q", s)
+	}
+	body := s[1 : len(s)-1]
+	result := []*string{}
+	if body == "" {
+		return result, nil
+	}
+	for i := 0; i <= len(body); {
+		if i < len(body) && body[i] == '{' {
+			return nil, fmt.Errorf("multi-dimensional array %q is not supported", s)
+		}
+		var sb strings.Builder
+		quoted := false
+		if i < len(body) && body[i] == '"' {
+			quoted = true
+			i++
+			for ; i < len(body) && body[i] != '"'; i++ {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				sb.WriteByte(body[i])
+			}
+			if i >= len(body) {
+				return nil, fmt.Errorf("invalid array literal %q", s)
+			}
+			i++ // 跳过结束的引号
+		} else {
+			for ; i < len(body) && body[i] != ','; i++ {
+				sb.WriteByte(body[i])
+			}
+		}
+		if i < len(body) && body[i] != ',' {
+			return nil, fmt.Errorf("invalid array literal %q", s)
+		}
+		i++ // 跳过逗号
+
+		element := sb.String()
+		if !quoted {
+			element = strings.TrimSpace(element)
+			if strings.EqualFold(element, "NULL") {
+				result = append(result, nil)
+				continue
+			}
+		}
+		result = append(result, &element)
+	}
+	return result, nil
+}
+
+// scanPGArray 将数组字面量解析到切片，NULL元素为零值
+func scanPGArray(dest reflect.Value, s string, destIsPtr bool, destType reflect.Type) error {
+	elements, err := parsePGArray(s)
+	if err != nil {
+		return err
+	}
+	elemType := destType.Elem()
+	list := reflect.MakeSlice(destType, len(elements), len(elements))
+	for i, element := range elements {
+		if element == nil {
+			continue
+		}
+		ev := list.Index(i)
+		switch elemType.Kind() {
+		case reflect.String:
+			ev.SetString(*element)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(*element)
+			if err != nil {
+				return err
+			}
+			ev.SetBool(b)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i64, err := strconv.ParseInt(*element, 10, elemType.Bits())
+			if err != nil {
+				return err
+			}
+			ev.SetInt(i64)
+		case reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			u64, err := strconv.ParseUint(*element, 10, elemType.Bits())
+			if err != nil {
+				return err
+			}
+			ev.SetUint(u64)
+		case reflect.Float32, reflect.Float64:
+			f64, err := strconv.ParseFloat(*element, elemType.Bits())
+			if err != nil {
+				return err
+			}
+			ev.SetFloat(f64)
+		}
+	}
+	if destIsPtr {
+		data := reflect.New(destType)
+		data.Elem().Set(list)
+		dest.Set(data)
+	} else {
+		dest.Set(list)
+	}
+	return nil
+}
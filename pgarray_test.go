@@ -0,0 +1,168 @@
+package osm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestEncodePGArray(t *testing.T) {
+	o := &osmBase{dbType: dbTypePostgres, options: &Options{}}
+	tests := []struct {
+		name string
+		in   interface{}
+		want interface{}
+	}{
+		{"int64", []int64{1, 2, 3}, "{1,2,3}"},
+		{"string", []string{"a", `b"c`, `d\e`}, `{"a","b\"c","d\\e"}`},
+		{"bool", []bool{true, false}, "{true,false}"},
+		{"empty", []int{}, "{}"},
+		{"nil", []int(nil), nil},
+		{"nil element", []*string{nil}, "{NULL}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := o.encodePGArray(reflect.ValueOf(tt.in))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePGArray(t *testing.T) {
+	got, err := parsePGArray(`{a,"b,c",NULL,"NULL","x\"y"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []interface{}{"a", "b,c", nil, "NULL", `x"y`}
+	if len(got) != len(want) {
+		t.Fatalf("got %d elements, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if w == nil {
+			if got[i] != nil {
+				t.Errorf("element %d: got %q, want NULL", i, *got[i])
+			}
+			continue
+		}
+		if got[i] == nil || *got[i] != w {
+			t.Errorf("element %d: got %v, want %v", i, got[i], w)
+		}
+	}
+
+	for _, s := range []string{"1,2", "{{1},{2}}", `{"a}`} {
+		if _, err := parsePGArray(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestPGArrayScan(t *testing.T) {
+	o, mock := newMockOsm(t)
+	o.dbType = dbTypePostgres
+
+	type tagged struct {
+		ID     int64     `db:"id"`
+		Tags   []string  `db:"tags"`
+		Scores []float64 `db:"scores"`
+		Flags  []bool    `db:"flags"`
+		Refs   *[]int64  `db:"refs"`
+	}
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "tags", "scores", "flags", "refs"}).
+			AddRow(1, []byte(`{go,"a b"}`), "{1.5,2}", "{t,f}", "{3,NULL,4}"),
+	)
+
+	var item tagged
+	_, err := o.SelectStruct("SELECT id, tags, scores, flags, refs FROM items")(&item)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(item.Tags, []string{"go", "a b"}) {
+		t.Errorf("Tags = %v", item.Tags)
+	}
+	if !reflect.DeepEqual(item.Scores, []float64{1.5, 2}) {
+		t.Errorf("Scores = %v", item.Scores)
+	}
+	if !reflect.DeepEqual(item.Flags, []bool{true, false}) {
+		t.Errorf("Flags = %v", item.Flags)
+	}
+	if item.Refs == nil || !reflect.DeepEqual(*item.Refs, []int64{3, 0, 4}) {
+		t.Errorf("Refs = %v", item.Refs)
+	}
+}
+
+func TestPGArrayParams(t *testing.T) {
+	t.Run("ANY with slice param", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.dbType = dbTypePostgres
+		mock.ExpectQuery(`SELECT id FROM users WHERE id = ANY\(\$1\)`).
+			WithArgs("{1,2,3}").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		var ids []int64
+		_, err := o.SelectValues("SELECT id FROM users WHERE id = ANY(#{ids})", []int64{1, 2, 3})(&ids)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("array column from struct", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.dbType = dbTypeCockroach
+		mock.ExpectPrepare(`UPDATE items SET tags = \$1 WHERE id = \$2`).
+			ExpectExec().
+			WithArgs(`{"x","y"}`, 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err := o.Update("UPDATE items SET tags = #{Tags} WHERE id = #{ID}", struct {
+			ID   int
+			Tags []string
+		}{7, []string{"x", "y"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("IN still expands", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.dbType = dbTypePostgres
+		mock.ExpectQuery(`SELECT id FROM users WHERE id IN \(\$1,\$2\)`).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		var ids []int64
+		_, err := o.SelectValues("SELECT id FROM users WHERE id IN #{ids}", []int{1, 2})(&ids)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestSQLIsArray(t *testing.T) {
+	for s, want := range map[string]bool{
+		"id = ANY(":    true,
+		"id <> all ( ": true,
+		"id IN (":      false,
+		"company(":     false,
+		"id = ANY ( ":  true,
+	} {
+		if got := sqlIsArray(s); got != want {
+			t.Errorf("sqlIsArray(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
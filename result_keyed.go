@@ -0,0 +1,222 @@
+package osm
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// IndexBy 查询多行数据，按key存入map[K]T或map[K]*T，相同key的后一行覆盖前一行
+//
+// key为作为键的列名或struct成员名。K为struct时是组合键，K的每个成员按db标签或成员名
+// 对应到T的成员，此时key可以为空。
+//
+// 用法:
+//
+//	var users map[int64]*User
+//	_, err = o.Select(`SELECT * FROM users`).IndexBy("id", &users)
+//
+//	type orderKey struct {
+//		CustomerID int64
+//		OrderDate  string
+//	}
+//	var orders map[orderKey]Order
+//	_, err = o.Select(`SELECT * FROM orders`).IndexBy("", &orders)
+func (sr *SelectResult) IndexBy(key string, container interface{}) (int64, error) {
+	if sr.err != nil {
+		return 0, sr.err
+	}
+	return resultKeyed(sr, key, container, false)
+}
+
+// GroupBy 查询多行数据，按key分组存入map[K][]T或map[K][]*T，组内保持查询结果的顺序
+//
+// key的规则与IndexBy相同。
+//
+// 用法:
+//
+//	var ordersByCustomer map[int64][]Order
+//	_, err = o.Select(`SELECT * FROM orders`).GroupBy("customer_id", &ordersByCustomer)
+func (sr *SelectResult) GroupBy(key string, container interface{}) (int64, error) {
+	if sr.err != nil {
+		return 0, sr.err
+	}
+	return resultKeyed(sr, key, container, true)
+}
+
+// resultKeyed 数据库结果读入到map[K]T、map[K]*T(group为false)或map[K][]T、map[K][]*T(group为true)
+func resultKeyed(sr *SelectResult, key string, container interface{}, group bool) (int64, error) {
+	id := sr.sql
+	kind := "indexBy"
+	if group {
+		kind = "groupBy"
+	}
+
+	pointValue := reflect.ValueOf(container)
+	if pointValue.Kind() != reflect.Ptr {
+		return 0, fmt.Errorf("sql '%s' error : %s类型Query，查询结果类型应为map的指针，而您传入的并不是指针", id, kind)
+	}
+	value := reflect.Indirect(pointValue)
+	if value.Kind() != reflect.Map {
+		return 0, fmt.Errorf("sql '%s' error : %s类型Query，查询结果类型应为map的指针，而您传入的并不是map", id, kind)
+	}
+	mapType := value.Type()
+
+	rowType := mapType.Elem()
+	if group {
+		if rowType.Kind() != reflect.Slice {
+			return 0, fmt.Errorf("sql '%s' error : groupBy类型Query，map的值应为struct切片", id)
+		}
+		rowType = rowType.Elem()
+	}
+	isStructPtr := rowType.Kind() == reflect.Ptr
+	structType := rowType
+	if isStructPtr {
+		structType = rowType.Elem()
+	}
+	if !isRowStruct(structType) {
+		return 0, fmt.Errorf("sql '%s' error : %s类型Query，map的值应为struct或struct的指针", id, kind)
+	}
+
+	tagMap := map[string]*structFieldInfo{}
+	nameMap := map[string]*structFieldInfo{}
+	if err := getStructFieldMap(structType, tagMap, nameMap); err != nil {
+		return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+	}
+	mapper := sr.osmBase.options.nameMapper()
+	keyOf, keyFields, err := keyBuilder(mapper, id, mapType.Key(), key, tagMap, nameMap)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := sr.Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	// 键对应的列应在查询结果中，否则每行的键都是零值，后一行会覆盖前一行
+	selected := map[*structFieldInfo]bool{}
+	for _, col := range rows.Columns() {
+		if field, _ := findFieldBy(mapper, tagMap, nameMap, col); field != nil {
+			selected[field] = true
+		}
+	}
+	for _, field := range keyFields {
+		if !selected[field] {
+			return 0, fmt.Errorf("sql '%s' error : 查询结果中没有作为map键的成员'%s'对应的列", id, field.n)
+		}
+	}
+
+	if value.IsNil() {
+		value.Set(reflect.MakeMap(mapType))
+	}
+	var rowsCount int64
+	for rows.Next() {
+		row := reflect.New(structType)
+		if err := rows.Scan(row.Interface()); err != nil {
+			return 0, err
+		}
+		k, err := keyOf(row.Elem())
+		if err != nil {
+			return 0, err
+		}
+		elem := row.Elem()
+		if isStructPtr {
+			elem = row
+		}
+		if group {
+			list := value.MapIndex(k)
+			if !list.IsValid() {
+				list = reflect.Zero(mapType.Elem())
+			}
+			value.SetMapIndex(k, reflect.Append(list, elem))
+		} else {
+			value.SetMapIndex(k, elem)
+		}
+		rowsCount++
+	}
+	return rowsCount, rows.Err()
+}
+
+// keyBuilder 返回从struct行中取得map键的函数，以及键对应的行成员
+func keyBuilder(mapper NameMapper, id string, keyType reflect.Type, key string, tagMap, nameMap map[string]*structFieldInfo) (func(row reflect.Value) (reflect.Value, error), []*structFieldInfo, error) {
+	lookup := func(name string) (*structFieldInfo, error) {
+		if field, err := findFieldBy(mapper, tagMap, nameMap, name); field != nil || err != nil {
+			return field, err
+		}
+		if field, ok := nameMap[name]; ok {
+			return field.checkAmbiguous(name)
+		}
+		return nil, nil
+	}
+
+	if !isRowStruct(keyType) {
+		field, err := lookup(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+		}
+		if field == nil {
+			return nil, nil, fmt.Errorf("sql '%s' error : 找不到作为map键的列'%s'", id, key)
+		}
+		return func(row reflect.Value) (reflect.Value, error) {
+			return keyValue(id, structFieldValue(row, field), keyType, key)
+		}, []*structFieldInfo{field}, nil
+	}
+
+	// 组合键，K的每个成员对应行的一个成员
+	keyFields := make([]*structFieldInfo, keyType.NumField())
+	for i := 0; i < keyType.NumField(); i++ {
+		f := keyType.Field(i)
+		if !f.IsExported() {
+			return nil, nil, fmt.Errorf("sql '%s' error : 组合键成员'%s'未导出，无法赋值", id, f.Name)
+		}
+		name, _ := parseDBTag(f.Tag.Get("db"))
+		if name == "" {
+			name = f.Name
+		}
+		field, err := lookup(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+		}
+		keyFields[i] = field
+		if keyFields[i] == nil {
+			return nil, nil, fmt.Errorf("sql '%s' error : 找不到组合键成员'%s'对应的列", id, f.Name)
+		}
+	}
+	return func(row reflect.Value) (reflect.Value, error) {
+		k := reflect.New(keyType).Elem()
+		for i, field := range keyFields {
+			v, err := keyValue(id, structFieldValue(row, field), keyType.Field(i).Type, keyType.Field(i).Name)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			k.Field(i).Set(v)
+		}
+		return k, nil
+	}, keyFields, nil
+}
+
+// keyValue 将成员的值转为键的类型，指针成员会解引用，NULL不能作为键
+func keyValue(id string, v reflect.Value, keyType reflect.Type, name string) (reflect.Value, error) {
+	if !v.IsValid() {
+		// 键所在的嵌入struct指针为nil
+		return reflect.Value{}, fmt.Errorf("sql '%s' error : map键'%s'为NULL", id, name)
+	}
+	if v.Kind() == reflect.Ptr && keyType.Kind() != reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("sql '%s' error : map键'%s'为NULL", id, name)
+		}
+		v = v.Elem()
+	}
+	if v.Type().AssignableTo(keyType) {
+		return v, nil
+	}
+	if keyType.Kind() == reflect.String && v.Kind() != reflect.String {
+		// 避免整数按rune转为字符串
+		return reflect.ValueOf(asString(v.Interface())).Convert(keyType), nil
+	}
+	if v.Type().ConvertibleTo(keyType) {
+		return v.Convert(keyType), nil
+	}
+	return reflect.Value{}, fmt.Errorf("sql '%s' error : map键'%s'的类型%s无法转为%s", id, name, v.Type(), keyType)
+}
@@ -0,0 +1,119 @@
+package osm
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testOrder struct {
+	ID         int64  `db:"id"`
+	CustomerID int64  `db:"customer_id"`
+	Region     string `db:"region"`
+	Amount     int    `db:"amount"`
+}
+
+func TestIndexBy(t *testing.T) {
+	t.Run("scalar key with pointer elements", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		rows := sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Alice").
+			AddRow(2, "Bob")
+		mock.ExpectQuery("SELECT id, name FROM user").WillReturnRows(rows)
+
+		var users map[int64]*testUser
+		count, err := o.Select("SELECT id, name FROM user").IndexBy("id", &users)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 || len(users) != 2 {
+			t.Fatalf("count=%d len=%d", count, len(users))
+		}
+		if users[2] == nil || users[2].Name != "Bob" {
+			t.Errorf("got %+v", users[2])
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("composite struct key", func(t *testing.T) {
+		type key struct {
+			CustomerID int64  `db:"customer_id,pk"`
+			Region     string `db:"region"`
+		}
+		o, mock := newMockOsm(t)
+		rows := sqlmock.NewRows([]string{"id", "customer_id", "region", "amount"}).
+			AddRow(1, 7, "eu", 10).
+			AddRow(2, 7, "us", 20)
+		mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+		orders := map[key]testOrder{}
+		_, err := o.Select("SELECT id, customer_id, region, amount FROM orders").IndexBy("", &orders)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if orders[key{7, "us"}].ID != 2 || orders[key{7, "eu"}].Amount != 10 {
+			t.Errorf("got %+v", orders)
+		}
+	})
+
+	t.Run("unknown key column returns error", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		var users map[int64]testUser
+		_, err := o.Select("SELECT id, name FROM user").IndexBy("missing", &users)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("key column not selected returns error", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Alice").AddRow("Bob"))
+		var users map[int64]testUser
+		if _, err := o.Select("SELECT name FROM user").IndexBy("id", &users); err == nil {
+			t.Fatalf("expected error, got %+v", users)
+		}
+	})
+
+	t.Run("unexported composite key field returns error", func(t *testing.T) {
+		type key struct {
+			id int64
+		}
+		o, _ := newMockOsm(t)
+		var orders map[key]testOrder
+		if _, err := o.Select("SELECT id FROM orders").IndexBy("", &orders); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestGroupBy(t *testing.T) {
+	o, mock := newMockOsm(t)
+	rows := sqlmock.NewRows([]string{"id", "customer_id", "amount"}).
+		AddRow(1, 7, 10).
+		AddRow(2, 8, 20).
+		AddRow(3, 7, 30)
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+	var byCustomer map[int64][]*testOrder
+	count, err := o.Select("SELECT id, customer_id, amount FROM orders").GroupBy("CustomerID", &byCustomer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || len(byCustomer) != 2 {
+		t.Fatalf("count=%d groups=%d", count, len(byCustomer))
+	}
+	if len(byCustomer[7]) != 2 || byCustomer[7][0].ID != 1 || byCustomer[7][1].ID != 3 {
+		t.Errorf("group 7: got %+v", byCustomer[7])
+	}
+	if len(byCustomer[8]) != 1 || byCustomer[8][0].Amount != 20 {
+		t.Errorf("group 8: got %+v", byCustomer[8])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -22,11 +22,11 @@ func resultKvs(logPrefix string, o *osmBase, id, sql string, sqlParams []interfa
 	kType := cType.Key()
 	vType := cType.Elem()
 	fields := []*structFieldInfo{
-		{0, "", &kType, false, kType.Kind() == reflect.Ptr},
-		{0, "", &vType, false, vType.Kind() == reflect.Ptr},
+		{t: &kType, isPtr: kType.Kind() == reflect.Ptr},
+		{t: &vType, isPtr: vType.Kind() == reflect.Ptr},
 	}
 
-	rows, err := o.db.Query(sql, sqlParams...)
+	rows, err := o.db.QueryContext(o.context(), sql, sqlParams...)
 	if err != nil {
 		return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
 	}
@@ -0,0 +1,151 @@
+package osm
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Map 查询单行数据，返回列名到值的map，没有数据时返回nil
+//
+// []byte会转为string(二进制列除外)，时间保持time.Time。
+// 开启Options.MapColumnTypes后，会根据列类型将数值列转为int64、float64或decimal字符串。
+//
+// 用法:
+//
+//	user, err := o.Select(`SELECT * FROM users WHERE id = #{Id}`, 1).Map()
+func (sr *SelectResult) Map() (map[string]interface{}, error) {
+	rows, err := sr.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	return rows.ScanMap()
+}
+
+// Maps 查询多行数据，每行为列名到值的map
+//
+// 用法:
+//
+//	users, err := o.Select(`SELECT * FROM users`).Maps()
+func (sr *SelectResult) Maps() ([]map[string]interface{}, error) {
+	rows, err := sr.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []map[string]interface{}
+	for rows.Next() {
+		m, err := rows.ScanMap()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
+
+// ScanMap 将当前行读为列名到值的map，值的转换规则与SelectResult.Map相同
+func (r *Rows) ScanMap() (map[string]interface{}, error) {
+	if err := r.loadColumnTypes(); err != nil {
+		return nil, err
+	}
+
+	refs := make([]interface{}, len(r.columns))
+	for i := range refs {
+		refs[i] = new(interface{})
+	}
+	if err := r.rows.Scan(refs...); err != nil {
+		return nil, fmt.Errorf("sql '%s' error : %s", r.id, err.Error())
+	}
+
+	m := make(map[string]interface{}, len(r.columns))
+	for i, column := range r.columns {
+		var columnType *sql.ColumnType
+		if i < len(r.columnTypes) {
+			columnType = r.columnTypes[i]
+		}
+		m[column] = normalizeMapValue(*(refs[i].(*interface{})), columnType, r.o.options.MapColumnTypes)
+	}
+	return m, nil
+}
+
+// normalizeMapValue 将driver返回的值转为Map/Maps中的值
+func normalizeMapValue(src interface{}, columnType *sql.ColumnType, useColumnType bool) interface{} {
+	typeName := ""
+	if columnType != nil {
+		typeName = strings.ToUpper(columnType.DatabaseTypeName())
+	}
+
+	if b, ok := src.([]byte); ok {
+		if isBinaryColumn(typeName) {
+			return append([]byte(nil), b...)
+		}
+		src = string(b)
+	}
+
+	s, ok := src.(string)
+	if !ok || !useColumnType {
+		return src
+	}
+	switch {
+	case isIntColumn(typeName):
+		if i64, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i64
+		}
+		if u64, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u64
+		}
+	case isFloatColumn(typeName):
+		if f64, err := strconv.ParseFloat(s, 64); err == nil {
+			return f64
+		}
+	case isBoolColumn(typeName):
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	// DECIMAL、NUMERIC等保持字符串，避免丢失精度
+	return s
+}
+
+func isBinaryColumn(typeName string) bool {
+	if isFloatColumn(typeName) {
+		return false
+	}
+	return strings.Contains(typeName, "BLOB") ||
+		strings.Contains(typeName, "BINARY") ||
+		typeName == "BYTEA" ||
+		typeName == "IMAGE" ||
+		typeName == "RAW" ||
+		typeName == "LONG RAW"
+}
+
+func isIntColumn(typeName string) bool {
+	typeName = strings.TrimPrefix(typeName, "UNSIGNED ")
+	switch typeName {
+	case "INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT",
+		"INT2", "INT4", "INT8", "SERIAL", "BIGSERIAL", "SMALLSERIAL",
+		"INT16", "INT32", "INT64", "UINT8", "UINT16", "UINT32", "UINT64", "YEAR":
+		return true
+	}
+	return false
+}
+
+func isFloatColumn(typeName string) bool {
+	switch typeName {
+	case "FLOAT", "DOUBLE", "REAL", "FLOAT4", "FLOAT8", "FLOAT32", "FLOAT64", "DOUBLE PRECISION", "BINARY_FLOAT", "BINARY_DOUBLE":
+		return true
+	}
+	return false
+}
+
+func isBoolColumn(typeName string) bool {
+	return typeName == "BOOL" || typeName == "BOOLEAN"
+}
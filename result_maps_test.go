@@ -0,0 +1,97 @@
+package osm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSelectResultMap(t *testing.T) {
+	t.Run("bytes become strings and time is kept", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		created := time.Date(2024, 6, 15, 10, 30, 0, 0, time.UTC)
+		rows := sqlmock.NewRows([]string{"id", "name", "created_at", "deleted_at"}).
+			AddRow(int64(1), []byte("Alice"), created, nil)
+		mock.ExpectQuery("SELECT").WithArgs(1).WillReturnRows(rows)
+
+		m, err := o.Select("SELECT * FROM user WHERE id = #{id}", 1).Map()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m["id"] != int64(1) || m["name"] != "Alice" || m["deleted_at"] != nil {
+			t.Errorf("got %#v", m)
+		}
+		if got, ok := m["created_at"].(time.Time); !ok || !got.Equal(created) {
+			t.Errorf("created_at: got %#v", m["created_at"])
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("no rows returns nil", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		m, err := o.Select("SELECT id FROM user").Map()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != nil {
+			t.Errorf("got %#v, want nil", m)
+		}
+	})
+}
+
+func TestSelectResultMaps(t *testing.T) {
+	t.Run("column types", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.options.MapColumnTypes = true
+		rows := mock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("BIGINT", int64(0)),
+			sqlmock.NewColumn("score").OfType("DOUBLE", float64(0)),
+			sqlmock.NewColumn("price").OfType("DECIMAL", ""),
+			sqlmock.NewColumn("avatar").OfType("BLOB", []byte(nil)),
+		).
+			AddRow([]byte("1"), []byte("9.5"), []byte("12.50"), []byte{0xff, 0x00}).
+			AddRow([]byte("2"), []byte("7"), []byte("0.10"), nil)
+		mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+		ms, err := o.Select("SELECT id, score, price, avatar FROM user").Maps()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ms) != 2 {
+			t.Fatalf("len: got %d, want 2", len(ms))
+		}
+		if ms[0]["id"] != int64(1) || ms[0]["score"] != 9.5 || ms[0]["price"] != "12.50" {
+			t.Errorf("row 0: got %#v", ms[0])
+		}
+		if b, ok := ms[0]["avatar"].([]byte); !ok || len(b) != 2 || b[0] != 0xff {
+			t.Errorf("avatar: got %#v", ms[0]["avatar"])
+		}
+		if ms[1]["id"] != int64(2) || ms[1]["score"] != float64(7) || ms[1]["avatar"] != nil {
+			t.Errorf("row 1: got %#v", ms[1])
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("without column types numbers stay strings", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		rows := mock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("BIGINT", int64(0)),
+		).AddRow([]byte("1"))
+		mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+		ms, err := o.Select("SELECT id FROM user").Maps()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ms) != 1 || ms[0]["id"] != "1" {
+			t.Errorf("got %#v", ms)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package osm
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ColumnInfo 结果集中一列的类型信息，来自sql.ColumnType
+//
+// 驱动不支持的信息对应的Has*/NullableKnown为false。
+type ColumnInfo struct {
+	Name              string       // 列名
+	DatabaseType      string       // 数据库类型名，如"VARCHAR"、"DECIMAL"、"INT8"
+	Nullable          bool         // 是否可为NULL
+	NullableKnown     bool         // 驱动是否提供了Nullable
+	Length            int64        // 变长类型的长度，如VARCHAR(255)为255
+	HasLength         bool         // 驱动是否提供了Length
+	Precision         int64        // DECIMAL等类型的精度
+	Scale             int64        // DECIMAL等类型的小数位数
+	HasPrecisionScale bool         // 驱动是否提供了Precision和Scale
+	ScanType          reflect.Type // 驱动建议的Go接收类型
+}
+
+// Meta 执行查询并返回结果集各列的类型信息，不读取数据
+//
+// 用法:
+//
+//	columns, err := o.Select(`SELECT id, price FROM products`).Meta()
+//	for _, c := range columns {
+//		fmt.Println(c.Name, c.DatabaseType, c.Precision, c.Scale)
+//	}
+func (sr *SelectResult) Meta() ([]ColumnInfo, error) {
+	rows, err := sr.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.ColumnInfos()
+}
+
+// ColumnInfos 结果集各列的类型信息
+func (r *Rows) ColumnInfos() ([]ColumnInfo, error) {
+	if err := r.loadColumnTypes(); err != nil {
+		return nil, err
+	}
+	infos := make([]ColumnInfo, len(r.columnTypes))
+	for i, ct := range r.columnTypes {
+		info := ColumnInfo{
+			Name:         ct.Name(),
+			DatabaseType: ct.DatabaseTypeName(),
+			ScanType:     ct.ScanType(),
+		}
+		info.Nullable, info.NullableKnown = ct.Nullable()
+		info.Length, info.HasLength = ct.Length()
+		info.Precision, info.Scale, info.HasPrecisionScale = ct.DecimalSize()
+		infos[i] = info
+	}
+	return infos, nil
+}
+
+func (r *Rows) loadColumnTypes() error {
+	if r.columnTypes != nil {
+		return nil
+	}
+	columnTypes, err := r.rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("sql '%s' error : %s", r.id, err.Error())
+	}
+	r.columnTypes = columnTypes
+	return nil
+}
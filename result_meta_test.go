@@ -0,0 +1,41 @@
+package osm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSelectResultMeta(t *testing.T) {
+	o, mock := newMockOsm(t)
+	rows := mock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("BIGINT", int64(0)).Nullable(false),
+		sqlmock.NewColumn("name").OfType("VARCHAR", "").WithLength(255).Nullable(true),
+		sqlmock.NewColumn("price").OfType("DECIMAL", "").WithPrecisionAndScale(10, 2),
+	)
+	mock.ExpectQuery("SELECT").WillReturnRows(rows).RowsWillBeClosed()
+
+	infos, err := o.Select("SELECT id, name, price FROM product").Meta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 3 {
+		t.Fatalf("len: got %d, want 3", len(infos))
+	}
+	if infos[0].Name != "id" || infos[0].DatabaseType != "BIGINT" || infos[0].ScanType != reflect.TypeOf(int64(0)) {
+		t.Errorf("id: got %+v", infos[0])
+	}
+	if !infos[0].NullableKnown || infos[0].Nullable {
+		t.Errorf("id nullable: got %+v", infos[0])
+	}
+	if !infos[1].HasLength || infos[1].Length != 255 || !infos[1].Nullable {
+		t.Errorf("name: got %+v", infos[1])
+	}
+	if !infos[2].HasPrecisionScale || infos[2].Precision != 10 || infos[2].Scale != 2 {
+		t.Errorf("price: got %+v", infos[2])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
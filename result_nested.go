@@ -0,0 +1,313 @@
+package osm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// nestedMapping struct与结果列的对应关系，包含带prefix选项的嵌套struct和struct切片
+//
+// 如下struct，SELECT o.id, o.no, i.id AS i_id, i.name AS i_name FROM orders o JOIN items i ...
+// 的结果会按Order的pk合并，每个Order的Items为该订单的全部item:
+//
+//	type Order struct {
+//		ID    int64  `db:"id,pk"`
+//		No    string `db:"no"`
+//		Items []Item `db:"items,prefix=i_"`
+//	}
+type nestedMapping struct {
+	structType reflect.Type
+	columns    []int              // 本层成员对应的列下标
+	fields     []*structFieldInfo // 与columns一一对应
+	keys       []int              // 用于合并重复行的列下标，有pk成员时为pk列，否则为本层全部列
+	ones       []*nestedChild     // 一对一的嵌套struct
+	manys      []*nestedChild     // 一对多的struct切片
+}
+
+type nestedChild struct {
+	field   *structFieldInfo // 父struct中存放子对象的成员
+	isPtr   bool             // 子对象(或切片元素)是否为指针
+	mapping *nestedMapping
+}
+
+type nestedColumn struct {
+	index int    // 在结果集中的下标
+	name  string // 去掉前缀后的列名
+}
+
+// nestedNode 合并过程中的一个对象，全部行读完后再写入父对象，避免值类型的切片元素被复制后丢失子对象
+type nestedNode struct {
+	obj   reflect.Value // *T
+	ones  []*nestedNode
+	manys [][]*nestedNode
+	seen  []map[string]*nestedNode // 每个一对多子集合中已出现的key
+}
+
+// hasNestedFields 判断struct是否有带prefix选项的成员
+func hasNestedFields(structType reflect.Type) bool {
+	tagMap := map[string]*structFieldInfo{}
+	nameMap := map[string]*structFieldInfo{}
+	// 标签的错误由之后读取结果时返回
+	_ = getStructFieldMap(structType, tagMap, nameMap)
+	for _, field := range nameMap {
+		if field.prefix != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func buildNestedMapping(mapper NameMapper, structType reflect.Type, columns []nestedColumn) (*nestedMapping, error) {
+	tagMap := map[string]*structFieldInfo{}
+	nameMap := map[string]*structFieldInfo{}
+	if err := getStructFieldMap(structType, tagMap, nameMap); err != nil {
+		return nil, err
+	}
+
+	var children []*structFieldInfo
+	for name, field := range nameMap {
+		if field.prefix != "" {
+			children = append(children, field)
+			delete(nameMap, name)
+		}
+	}
+	for tag, field := range tagMap {
+		if field.prefix != "" {
+			delete(tagMap, tag)
+		}
+	}
+	// 前缀长的优先匹配
+	sort.Slice(children, func(i, j int) bool {
+		if len(children[i].prefix) != len(children[j].prefix) {
+			return len(children[i].prefix) > len(children[j].prefix)
+		}
+		return children[i].n < children[j].n
+	})
+
+	m := &nestedMapping{structType: structType}
+	childColumns := make([][]nestedColumn, len(children))
+	for _, col := range columns {
+		matched := false
+		for ci, child := range children {
+			if strings.HasPrefix(col.name, child.prefix) {
+				childColumns[ci] = append(childColumns[ci], nestedColumn{col.index, col.name[len(child.prefix):]})
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		field, err := findFieldBy(mapper, tagMap, nameMap, col.name)
+		if err != nil {
+			return nil, err
+		}
+		if field != nil {
+			m.columns = append(m.columns, col.index)
+			m.fields = append(m.fields, field)
+			if field.pk {
+				m.keys = append(m.keys, col.index)
+			}
+		}
+	}
+	if len(m.keys) == 0 {
+		m.keys = m.columns
+	}
+
+	for ci, field := range children {
+		elemType := *(field.t)
+		many := elemType.Kind() == reflect.Slice
+		if many {
+			elemType = elemType.Elem()
+		}
+		isPtr := elemType.Kind() == reflect.Ptr
+		if isPtr {
+			elemType = elemType.Elem()
+		}
+		if !isRowStruct(elemType) {
+			return nil, fmt.Errorf("成员'%s'的prefix选项只能用于struct、struct指针或struct切片", field.n)
+		}
+		childMapping, err := buildNestedMapping(mapper, elemType, childColumns[ci])
+		if err != nil {
+			return nil, err
+		}
+		child := &nestedChild{field: field, isPtr: isPtr, mapping: childMapping}
+		if many {
+			m.manys = append(m.manys, child)
+		} else {
+			m.ones = append(m.ones, child)
+		}
+	}
+	return m, nil
+}
+
+// key 该行在本层的唯一标识
+func (m *nestedMapping) key(raw []interface{}) string {
+	var sb strings.Builder
+	for _, col := range m.keys {
+		if raw[col] == nil {
+			sb.WriteString("\x01")
+		} else {
+			sb.WriteString(asString(raw[col]))
+		}
+		sb.WriteString("\x00")
+	}
+	return sb.String()
+}
+
+// isNull 本层的列是否全为NULL，如LEFT JOIN没有匹配的子行
+func (m *nestedMapping) isNull(raw []interface{}) bool {
+	for _, col := range m.columns {
+		if raw[col] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *osmBase) newNestedNode(logPrefix string, m *nestedMapping, raw []interface{}) (*nestedNode, error) {
+	obj := reflect.New(m.structType)
+	for i, col := range m.columns {
+		field := m.fields[i]
+		if err := o.assignField(logPrefix, field, structFieldAlloc(obj.Elem(), field), raw[col]); err != nil {
+			return nil, err
+		}
+	}
+	return &nestedNode{
+		obj:   obj,
+		ones:  make([]*nestedNode, len(m.ones)),
+		manys: make([][]*nestedNode, len(m.manys)),
+		seen:  make([]map[string]*nestedNode, len(m.manys)),
+	}, nil
+}
+
+// mergeNestedRow 将一行数据合并到node的子对象中
+func (o *osmBase) mergeNestedRow(logPrefix string, m *nestedMapping, node *nestedNode, raw []interface{}) error {
+	for i, child := range m.ones {
+		if node.ones[i] == nil {
+			if child.mapping.isNull(raw) {
+				continue
+			}
+			childNode, err := o.newNestedNode(logPrefix, child.mapping, raw)
+			if err != nil {
+				return err
+			}
+			node.ones[i] = childNode
+		}
+		if err := o.mergeNestedRow(logPrefix, child.mapping, node.ones[i], raw); err != nil {
+			return err
+		}
+	}
+	for i, child := range m.manys {
+		if child.mapping.isNull(raw) {
+			continue
+		}
+		if node.seen[i] == nil {
+			node.seen[i] = map[string]*nestedNode{}
+		}
+		key := child.mapping.key(raw)
+		childNode, ok := node.seen[i][key]
+		if !ok {
+			var err error
+			childNode, err = o.newNestedNode(logPrefix, child.mapping, raw)
+			if err != nil {
+				return err
+			}
+			node.seen[i][key] = childNode
+			node.manys[i] = append(node.manys[i], childNode)
+		}
+		if err := o.mergeNestedRow(logPrefix, child.mapping, childNode, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// materialize 将子对象写入node的成员，返回*T
+func (m *nestedMapping) materialize(node *nestedNode) reflect.Value {
+	elem := node.obj.Elem()
+	for i, child := range m.ones {
+		if node.ones[i] == nil {
+			continue
+		}
+		v := child.mapping.materialize(node.ones[i])
+		if child.isPtr {
+			structFieldAlloc(elem, child.field).Set(v)
+		} else {
+			structFieldAlloc(elem, child.field).Set(v.Elem())
+		}
+	}
+	for i, child := range m.manys {
+		fieldValue := structFieldAlloc(elem, child.field)
+		list := reflect.MakeSlice(fieldValue.Type(), 0, len(node.manys[i]))
+		for _, childNode := range node.manys[i] {
+			v := child.mapping.materialize(childNode)
+			if child.isPtr {
+				list = reflect.Append(list, v)
+			} else {
+				list = reflect.Append(list, v.Elem())
+			}
+		}
+		fieldValue.Set(list)
+	}
+	return node.obj
+}
+
+// resultNested 将JOIN的结果合并为对象图，返回各顶层对象的指针(*T)
+func resultNested(logPrefix string, o *osmBase, id, sql string, sqlParams []interface{}, structType reflect.Type) ([]reflect.Value, error) {
+	rows, err := o.db.QueryContext(o.context(), sql, sqlParams...)
+	if err != nil {
+		return nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+	}
+	nestedColumns := make([]nestedColumn, len(columns))
+	for i, col := range columns {
+		nestedColumns[i] = nestedColumn{i, col}
+	}
+	mapping, err := buildNestedMapping(o.options.nameMapper(), structType, nestedColumns)
+	if err != nil {
+		return nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+	}
+
+	var roots []*nestedNode
+	seen := map[string]*nestedNode{}
+	raw := make([]interface{}, len(columns))
+	refs := make([]interface{}, len(columns))
+	for i := range refs {
+		refs[i] = &raw[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(refs...); err != nil {
+			return nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+		}
+		key := mapping.key(raw)
+		node, ok := seen[key]
+		if !ok {
+			node, err = o.newNestedNode(logPrefix, mapping, raw)
+			if err != nil {
+				return nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+			}
+			seen[key] = node
+			roots = append(roots, node)
+		}
+		if err := o.mergeNestedRow(logPrefix, mapping, node, raw); err != nil {
+			return nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+	}
+
+	objs := make([]reflect.Value, len(roots))
+	for i, node := range roots {
+		objs[i] = mapping.materialize(node)
+	}
+	return objs, nil
+}
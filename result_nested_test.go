@@ -0,0 +1,92 @@
+package osm
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testItem struct {
+	ID   int64  `db:"id,pk"`
+	Name string `db:"name"`
+}
+
+type testCustomer struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+type testOrderWithItems struct {
+	ID       int64         `db:"id,pk"`
+	No       string        `db:"no"`
+	Customer *testCustomer `db:"customer,prefix=c_"`
+	Items    []testItem    `db:"items,prefix=i_"`
+}
+
+func TestResultNested(t *testing.T) {
+	t.Run("join rows collapse into parents", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		rows := sqlmock.NewRows([]string{"id", "no", "c_id", "c_name", "i_id", "i_name"}).
+			AddRow(1, "A001", 7, "Alice", 10, "pen").
+			AddRow(1, "A001", 7, "Alice", 11, "ink").
+			AddRow(2, "A002", nil, nil, 12, "book").
+			AddRow(3, "A003", 8, "Bob", nil, nil).
+			AddRow(1, "A001", 7, "Alice", 10, "pen")
+		mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+		var orders []testOrderWithItems
+		count, err := o.Select("SELECT o.id, o.no, c.id AS c_id, c.name AS c_name, i.id AS i_id, i.name AS i_name FROM orders o").Structs(&orders)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 3 || len(orders) != 3 {
+			t.Fatalf("count=%d len=%d", count, len(orders))
+		}
+		if orders[0].No != "A001" || len(orders[0].Items) != 2 || orders[0].Items[1].Name != "ink" {
+			t.Errorf("order 0: got %+v", orders[0])
+		}
+		if orders[0].Customer == nil || orders[0].Customer.Name != "Alice" {
+			t.Errorf("order 0 customer: got %+v", orders[0].Customer)
+		}
+		if orders[1].Customer != nil || len(orders[1].Items) != 1 {
+			t.Errorf("order 1: got %+v", orders[1])
+		}
+		if orders[2].Customer == nil || orders[2].Customer.ID != 8 || len(orders[2].Items) != 0 {
+			t.Errorf("order 2: got %+v", orders[2])
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("single struct collects all children", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		rows := sqlmock.NewRows([]string{"id", "no", "i_id", "i_name"}).
+			AddRow(1, "A001", 10, "pen").
+			AddRow(1, "A001", 11, "ink")
+		mock.ExpectQuery("SELECT").WithArgs(1).WillReturnRows(rows)
+
+		var order *testOrderWithItems
+		count, err := o.Select("SELECT * FROM orders o WHERE o.id = #{id}", 1).Struct(&order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 || order == nil || len(order.Items) != 2 {
+			t.Errorf("count=%d order=%+v", count, order)
+		}
+	})
+
+	t.Run("prefix on non-struct field returns error", func(t *testing.T) {
+		type bad struct {
+			ID   int64    `db:"id,pk"`
+			Tags []string `db:"tags,prefix=t_"`
+		}
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "t_name"}).AddRow(1, "x"))
+
+		var list []bad
+		if _, err := o.Select("SELECT * FROM t").Structs(&list); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,256 @@
+package osm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"time"
+)
+
+// ErrStop 在Each的回调中返回ErrStop可提前结束遍历，Each本身不会返回该错误
+var ErrStop = errors.New("osm: stop iteration")
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// isRowStruct 判断类型是否按struct方式映射一行数据(time.Time、big.Int等数值和实现了sql.Scanner的类型按单个值处理)
+func isRowStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !isBigNumberType(t) && !reflect.PointerTo(t).Implements(scannerType)
+}
+
+// Rows 查询结果游标，逐行读取数据，不会将全部结果读入内存
+//
+// 用法:
+//
+//	rows, err := o.Select(`SELECT * FROM users`).Rows()
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//		var user User
+//		if err := rows.Scan(&user); err != nil {
+//			return err
+//		}
+//	}
+//	return rows.Err()
+type Rows struct {
+	o         *osmBase
+	logPrefix string
+	id        string
+	rows      *sql.Rows
+	columns   []string
+
+	columnTypes []*sql.ColumnType  // 列类型，ScanMap或ColumnInfos时读取
+	structType  reflect.Type       // 上一次Scan的struct类型
+	fields      []*structFieldInfo // structType的成员与列的对应关系，同一类型只计算一次
+}
+
+// Rows 执行查询并返回游标，调用方必须调用Close
+func (sr *SelectResult) Rows() (*Rows, error) {
+	if sr.err != nil {
+		return nil, sr.err
+	}
+	return sr.osmBase.queryRows(sr.logPrefix, sr.sql, sr.sql, sr.sqlParams)
+}
+
+func (o *osmBase) queryRows(logPrefix, id, sql string, sqlParams []interface{}) (*Rows, error) {
+	rows, err := o.db.QueryContext(o.context(), sql, sqlParams...)
+	if err != nil {
+		return nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+	}
+	return &Rows{
+		o:         o,
+		logPrefix: logPrefix,
+		id:        id,
+		rows:      rows,
+		columns:   columns,
+	}, nil
+}
+
+// Next 移动到下一行，没有更多数据或出错时返回false，出错时可通过Err获得错误
+func (r *Rows) Next() bool {
+	return r.rows.Next()
+}
+
+// Columns 结果集的列名
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+// Scan 将当前行读入containers
+//
+// 只传入一个struct指针(或struct指针的指针)时按列名映射到struct成员，
+// 否则按顺序将各列读入containers，此时containers的数量应与列数一致。
+func (r *Rows) Scan(containers ...interface{}) error {
+	if len(containers) == 1 {
+		pointValue := reflect.ValueOf(containers[0])
+		if pointValue.Kind() != reflect.Ptr || pointValue.IsNil() {
+			return fmt.Errorf("sql '%s' error : Rows.Scan的参数应为指针", r.id)
+		}
+		value := pointValue.Elem()
+		if isRowStruct(value.Type()) {
+			return r.scanStruct(value)
+		}
+		if value.Kind() == reflect.Ptr && isRowStruct(value.Type().Elem()) {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			return r.scanStruct(value.Elem())
+		}
+	}
+	return r.scanValues(containers)
+}
+
+func (r *Rows) scanStruct(valueElem reflect.Value) error {
+	structType := valueElem.Type()
+	if r.structType != structType {
+		tagMap := map[string]*structFieldInfo{}
+		nameMap := map[string]*structFieldInfo{}
+		if err := getStructFieldMap(structType, tagMap, nameMap); err != nil {
+			return fmt.Errorf("sql '%s' error : %s", r.id, err.Error())
+		}
+		fields := make([]*structFieldInfo, len(r.columns))
+		for i, col := range r.columns {
+			field, err := findFieldBy(r.o.options.nameMapper(), tagMap, nameMap, col)
+			if err != nil {
+				return fmt.Errorf("sql '%s' error : %s", r.id, err.Error())
+			}
+			fields[i] = field
+		}
+		r.structType = structType
+		r.fields = fields
+	}
+	err := r.o.scanRow(r.logPrefix, r.rows, r.fields, structFieldValues(valueElem, r.fields))
+	if err != nil {
+		return fmt.Errorf("sql '%s' error : %s", r.id, err.Error())
+	}
+	return nil
+}
+
+func (r *Rows) scanValues(containers []interface{}) error {
+	if len(containers) != len(r.columns) {
+		return fmt.Errorf("sql '%s' error : Rows.Scan的参数数量与SQL查询的列数不一致", r.id)
+	}
+	values := make([]reflect.Value, len(containers))
+	fields := make([]*structFieldInfo, len(containers))
+	for i, container := range containers {
+		pointValue := reflect.ValueOf(container)
+		if pointValue.Kind() != reflect.Ptr || pointValue.IsNil() {
+			return fmt.Errorf("sql '%s' error : Rows.Scan的参数应为指针，而您传入的第%d个并不是指针", r.id, i+1)
+		}
+		value := pointValue.Elem()
+		valueType := value.Type()
+		fields[i] = &structFieldInfo{t: &valueType, isPtr: valueType.Kind() == reflect.Ptr}
+		values[i] = value
+	}
+	err := r.o.scanRow(r.logPrefix, r.rows, fields, values)
+	if err != nil {
+		return fmt.Errorf("sql '%s' error : %s", r.id, err.Error())
+	}
+	return nil
+}
+
+// Err 遍历过程中遇到的错误
+func (r *Rows) Err() error {
+	if err := r.rows.Err(); err != nil {
+		return fmt.Errorf("sql '%s' error : %s", r.id, err.Error())
+	}
+	return nil
+}
+
+// Close 关闭游标，释放连接，可重复调用
+func (r *Rows) Close() error {
+	return r.rows.Close()
+}
+
+// Each 逐行读取数据并调用fn，fn的类型应为func(row *T) error，T为struct或单个值的类型
+//
+// fn返回错误时停止遍历并返回该错误，返回ErrStop时停止遍历且不返回错误。
+// 每一行都会创建新的T，fn中可以保留row。
+//
+// 用法:
+//
+//	_, err = o.Select(`SELECT * FROM users`).Each(func(user *User) error {
+//		return encoder.Encode(user)
+//	})
+func (sr *SelectResult) Each(fn interface{}) (int64, error) {
+	if sr.err != nil {
+		return 0, sr.err
+	}
+	fnValue := reflect.ValueOf(fn)
+	if fnValue.Kind() != reflect.Func || fnValue.IsNil() ||
+		fnValue.Type().NumIn() != 1 || fnValue.Type().In(0).Kind() != reflect.Ptr ||
+		fnValue.Type().NumOut() != 1 || fnValue.Type().Out(0) != errorType {
+		return 0, fmt.Errorf("sql '%s' error : Each的参数应为func(row *T) error，而您传入的是%T", sr.sql, fn)
+	}
+	rowType := fnValue.Type().In(0).Elem()
+
+	rows, err := sr.Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var rowsCount int64
+	for rows.Next() {
+		row := reflect.New(rowType)
+		if err := rows.Scan(row.Interface()); err != nil {
+			return rowsCount, err
+		}
+		rowsCount++
+		if out := fnValue.Call([]reflect.Value{row})[0]; !out.IsNil() {
+			err := out.Interface().(error)
+			if errors.Is(err, ErrStop) {
+				return rowsCount, nil
+			}
+			return rowsCount, err
+		}
+	}
+	return rowsCount, rows.Err()
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Iterate 返回逐行读取数据的迭代器，可用于for range，T为struct或单个值的类型
+//
+// 出错时产出(nil, err)并结束，提前break会自动关闭游标。
+//
+// 用法:
+//
+//	for user, err := range osm.Iterate[User](o.Select(`SELECT * FROM users`)) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(user.Email)
+//	}
+func Iterate[T any](sr *SelectResult) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		rows, err := sr.Rows()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			row := new(T)
+			if err := rows.Scan(row); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
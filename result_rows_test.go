@@ -0,0 +1,186 @@
+package osm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRows(t *testing.T) {
+	t.Run("scan struct and values", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		rows := sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Alice").
+			AddRow(2, "Bob")
+		mock.ExpectQuery("SELECT id, name FROM user").
+			WillReturnRows(rows).
+			RowsWillBeClosed()
+
+		cursor, err := o.Select("SELECT id, name FROM user").Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cursor.Close()
+
+		if !cursor.Next() {
+			t.Fatal("expected first row")
+		}
+		var user testUser
+		if err := cursor.Scan(&user); err != nil {
+			t.Fatal(err)
+		}
+		if user.ID != 1 || user.Name != "Alice" {
+			t.Errorf("got %+v", user)
+		}
+
+		if !cursor.Next() {
+			t.Fatal("expected second row")
+		}
+		var id int64
+		var name string
+		if err := cursor.Scan(&id, &name); err != nil {
+			t.Fatal(err)
+		}
+		if id != 2 || name != "Bob" {
+			t.Errorf("got id=%d, name=%s", id, name)
+		}
+
+		if cursor.Next() {
+			t.Fatal("expected no more rows")
+		}
+		if err := cursor.Err(); err != nil {
+			t.Fatal(err)
+		}
+		cursor.Close()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("wrong value count returns error", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery("SELECT id, name FROM user").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Alice"))
+
+		cursor, err := o.Select("SELECT id, name FROM user").Rows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cursor.Close()
+		cursor.Next()
+		var id int64
+		if err := cursor.Scan(&id); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEach(t *testing.T) {
+	t.Run("visits every row", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		rows := sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Alice").
+			AddRow(2, "Bob")
+		mock.ExpectQuery("SELECT id, name FROM user").
+			WillReturnRows(rows).
+			RowsWillBeClosed()
+
+		var names []string
+		count, err := o.Select("SELECT id, name FROM user").Each(func(user *testUser) error {
+			names = append(names, user.Name)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 || len(names) != 2 || names[1] != "Bob" {
+			t.Errorf("count=%d names=%v", count, names)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("ErrStop ends early without error", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		rows := sqlmock.NewRows([]string{"id"}).
+			AddRow(1).
+			AddRow(2).
+			AddRow(3)
+		mock.ExpectQuery("SELECT id FROM user").
+			WillReturnRows(rows).
+			RowsWillBeClosed()
+
+		var ids []int64
+		count, err := o.Select("SELECT id FROM user").Each(func(id *int64) error {
+			ids = append(ids, *id)
+			if len(ids) == 2 {
+				return ErrStop
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 || len(ids) != 2 {
+			t.Errorf("count=%d ids=%v", count, ids)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("callback error is returned", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectQuery("SELECT id FROM user").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		want := errors.New("boom")
+		_, err := o.Select("SELECT id FROM user").Each(func(id *int64) error {
+			return want
+		})
+		if !errors.Is(err, want) {
+			t.Fatalf("got %v, want %v", err, want)
+		}
+	})
+
+	t.Run("invalid callback returns error", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		_, err := o.Select("SELECT id FROM user").Each(func(id int64) {})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestIterate(t *testing.T) {
+	o, mock := newMockOsm(t)
+	rows := sqlmock.NewRows([]string{"id", "name"}).
+		AddRow(1, "Alice").
+		AddRow(2, "Bob").
+		AddRow(3, "Charlie")
+	mock.ExpectQuery("SELECT id, name FROM user").
+		WillReturnRows(rows).
+		RowsWillBeClosed()
+
+	var users []*testUser
+	for user, err := range Iterate[testUser](o.Select("SELECT id, name FROM user")) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, user)
+		if len(users) == 2 {
+			break
+		}
+	}
+	if len(users) != 2 || users[0].Name != "Alice" || users[1].Name != "Bob" {
+		t.Errorf("got %+v", users)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -57,7 +57,7 @@ func resultStrings(logPrefix string, o *osmBase, id, sql string, sqlParams []int
 		return 0, fmt.Errorf("sql '%s' error : strings类型Query，查询结果类型第一个为[]string的指针，第二个为[][]string的指针", id)
 	}
 
-	rows, err := o.db.Query(sql, sqlParams...)
+	rows, err := o.db.QueryContext(o.context(), sql, sqlParams...)
 	if err != nil {
 		return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
 	}
@@ -74,7 +74,7 @@ func resultStrings(logPrefix string, o *osmBase, id, sql string, sqlParams []int
 			}
 			columnsCount = len(columns)
 			for _, column := range columns {
-				fields = append(fields, &structFieldInfo{0, "", &stringType, false, false})
+				fields = append(fields, &structFieldInfo{t: &stringType})
 				columnsValue.Set(reflect.Append(*columnsValue, reflect.ValueOf(column)))
 			}
 		}
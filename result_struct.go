@@ -20,7 +20,24 @@ func resultStruct(logPrefix string, o *osmBase, id, sql string, sqlParams []inte
 		return 0, fmt.Errorf("sql '%s' error : struct类型Query，查询结果类型应为struct的指针，而您传入的并不是struct", id)
 	}
 
-	rows, err := o.db.Query(sql, sqlParams...)
+	// 有带prefix选项的成员时，合并全部行后取第一个对象
+	if hasNestedFields(valueElem.Type()) {
+		objs, err := resultNested(logPrefix, o, id, sql, sqlParams, valueElem.Type())
+		if err != nil || len(objs) == 0 {
+			return 0, err
+		}
+		if err := afterScan(objs[0].Elem()); err != nil {
+			return 0, fmt.Errorf("sql '%s' error : AfterScan : %w", id, err)
+		}
+		if isStructPtr {
+			value.Set(objs[0])
+		} else {
+			value.Set(objs[0].Elem())
+		}
+		return 1, nil
+	}
+
+	rows, err := o.db.QueryContext(o.context(), sql, sqlParams...)
 	if err != nil {
 		return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
 	}
@@ -34,33 +51,29 @@ func resultStruct(logPrefix string, o *osmBase, id, sql string, sqlParams []inte
 	if err != nil {
 		return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
 	}
-	columnsCount := len(columns)
-	fields := make([]*structFieldInfo, columnsCount)
-	values := make([]reflect.Value, columnsCount)
+	fields := make([]*structFieldInfo, len(columns))
 
 	structType := valueElem.Type()
 	tagMap := make(map[string]*structFieldInfo)
 	nameMap := make(map[string]*structFieldInfo)
-	getStructFieldMap(structType, tagMap, nameMap, false)
+	if err := getStructFieldMap(structType, tagMap, nameMap); err != nil {
+		return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+	}
 
 	for i, col := range columns {
-		field := findField(tagMap, nameMap, col)
-		fields[i] = field
-		if field != nil {
-			if field.a {
-				values[i] = valueElem.FieldByName(field.n)
-			} else {
-				values[i] = valueElem.Field(field.i)
-			}
-		} else {
-			a := ""
-			values[i] = reflect.ValueOf(&a).Elem()
+		field, err := findFieldBy(o.options.nameMapper(), tagMap, nameMap, col)
+		if err != nil {
+			return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
 		}
+		fields[i] = field
 	}
-	err = o.scanRow(logPrefix, rows, fields, values)
+	err = o.scanRow(logPrefix, rows, fields, structFieldValues(valueElem, fields))
 	if err != nil {
 		return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
 	}
+	if err := afterScan(valueElem); err != nil {
+		return 0, fmt.Errorf("sql '%s' error : AfterScan : %w", id, err)
+	}
 	if isStructPtr {
 		value.Set(valueElem.Addr())
 	}
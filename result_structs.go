@@ -32,15 +32,36 @@ func resultStructs(logPrefix string, o *osmBase, id, sql string, sqlParams []int
 		return 0, fmt.Errorf("sql '%s' error : structs类型Query，查询结果类型应为struct切片的指针，而您传入的并不是struct", id)
 	}
 
+	// 有带prefix选项的成员时，按对象图合并JOIN的结果
+	if hasNestedFields(structType) {
+		objs, err := resultNested(logPrefix, o, id, sql, sqlParams, structType)
+		if err != nil {
+			return 0, err
+		}
+		for _, obj := range objs {
+			if err := afterScan(obj.Elem()); err != nil {
+				return 0, fmt.Errorf("sql '%s' error : AfterScan : %w", id, err)
+			}
+			if isStructPtr {
+				value.Set(reflect.Append(value, obj))
+			} else {
+				value.Set(reflect.Append(value, obj.Elem()))
+			}
+		}
+		return int64(len(objs)), nil
+	}
+
 	var rowsCount int64                      // 读取的行数，用于返回
 	var columnsCount int                     // 读取的列数
 	var fields []*structFieldInfo            // struct成员的名字，与sql中的列对应
 	tagMap := map[string]*structFieldInfo{}  // struct每个成员的tag，优先匹配
 	nameMap := map[string]*structFieldInfo{} // struct每个成员的名字，不一定与sql中的列对应
-	getStructFieldMap(structType, tagMap, nameMap, false)
+	if err := getStructFieldMap(structType, tagMap, nameMap); err != nil {
+		return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
+	}
 
 	// 使用提供的SQL，从数据库读取数据
-	rows, err := o.db.Query(sql, sqlParams...)
+	rows, err := o.db.QueryContext(o.context(), sql, sqlParams...)
 	if err != nil {
 		return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
 	}
@@ -61,28 +82,23 @@ func resultStructs(logPrefix string, o *osmBase, id, sql string, sqlParams []int
 			fields = make([]*structFieldInfo, columnsCount)
 			// 计算
 			for i, col := range columns {
-				fields[i] = findField(tagMap, nameMap, col)
-			}
-		}
-		// 通过fieldName,创建struct实列的成员实例切片
-		values := make([]reflect.Value, columnsCount)
-		for i, field := range fields {
-			if field != nil {
-				if field.a {
-					values[i] = valueElem.FieldByName(field.n)
-				} else {
-					values[i] = valueElem.Field(field.i)
+				field, err := findFieldBy(o.options.nameMapper(), tagMap, nameMap, col)
+				if err != nil {
+					return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
 				}
-			} else {
-				a := ""
-				values[i] = reflect.ValueOf(&a).Elem()
+				fields[i] = field
 			}
 		}
+		// 通过fieldName,创建struct实列的成员实例切片
+		values := structFieldValues(valueElem, fields)
 		// 读取一行数据到成员实例切片中
 		err = o.scanRow(logPrefix, rows, fields, values)
 		if err != nil {
 			return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
 		}
+		if err := afterScan(valueElem); err != nil {
+			return 0, fmt.Errorf("sql '%s' error : AfterScan : %w", id, err)
+		}
 		// struct实列装进结果切片
 		if isStructPtr {
 			value.Set(reflect.Append(value, valueElem.Addr()))
@@ -33,7 +33,7 @@ func resultValues(logPrefix string, o *osmBase, id, sql string, sqlParams, conta
 		}
 	}
 
-	rows, err := o.db.Query(sql, sqlParams...)
+	rows, err := o.db.QueryContext(o.context(), sql, sqlParams...)
 	if err != nil {
 		return 0, fmt.Errorf("sql '%s' error : %s", id, err.Error())
 	}
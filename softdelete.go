@@ -0,0 +1,104 @@
+package osm
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Unscoped 返回不处理软删除的Osm，GetByPK等方法会返回已软删除的行，DeleteByPK会直接删除行
+//
+// 软删除通过softdelete选项的成员声明，成员建议使用*time.Time或Null[time.Time]，整数类型的成员写入Unix时间戳。
+// Select等直接执行sql的方法不受影响。
+//
+// 代码
+//
+//	type User struct {
+//		ID        int64      `db:"id,pk,auto"`
+//		DeletedAt *time.Time `db:"deleted_at,softdelete"`
+//	}
+//
+//	count, err := o.Unscoped().GetByPK("", &user, 3)
+//
+// 返回的Osm与o共用连接，不能Close。
+func (o *Osm) Unscoped() *Osm {
+	unscoped := *o
+	unscoped.unscoped = true
+	unscoped.derived = true
+	return &unscoped
+}
+
+// Unscoped 返回不处理软删除的Tx，与Osm.Unscoped()相同
+func (o *Tx) Unscoped() *Tx {
+	unscoped := *o
+	unscoped.unscoped = true
+	return &unscoped
+}
+
+// notDeleted 查询和更新时排除已软删除的行的条件
+func (o *osmBase) notDeleted(t *crudTable) string {
+	if t.deleted == nil || o.unscoped {
+		return ""
+	}
+	return " AND " + o.quoteIdent(t.deleted.name) + " IS NULL"
+}
+
+// setDeleted 将行标记为已软删除，obj为指针时同时写入softdelete的成员
+func (o *osmBase) setDeleted(logPrefix string, t *crudTable, elem reflect.Value, where string) (int64, error) {
+	c := t.deleted
+	value := timeValue(c.field, o.options.now())
+	params, err := o.columnParams(elem, t.pks...)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteByPK error : %s", err.Error())
+	}
+	params[c.param] = value
+	name := o.quoteIdent(c.name)
+	sql := "UPDATE " + t.table + " SET " + name + " = #{" + c.param + "} WHERE " + where + " AND " + name + " IS NULL"
+	count, err := o.exec(logPrefix, sql, []interface{}{params})
+	if err != nil || count == 0 || !elem.CanAddr() {
+		return count, err
+	}
+	if err := o.setField(logPrefix, elem, c.field, value); err != nil {
+		return count, fmt.Errorf("DeleteByPK error : %s", err.Error())
+	}
+	return count, nil
+}
+
+// Restore 恢复已软删除的行，将softdelete的列设为NULL，table为空时使用obj的TableName()，返回影响的行数
+//
+// 代码
+//
+//	count, err := o.Restore("", &User{ID: 3})
+func (o *osmBase) Restore(table string, obj interface{}) (int64, error) {
+	return o.restore(getCallerInfo(2), table, obj)
+}
+
+func (o *osmBase) restore(logPrefix, table string, obj interface{}) (int64, error) {
+	elem, err := structOf(obj)
+	if err != nil {
+		return 0, fmt.Errorf("Restore error : %s", err.Error())
+	}
+	t, err := o.crudTableOf(table, elem.Type())
+	if err != nil {
+		return 0, fmt.Errorf("Restore error : %s", err.Error())
+	}
+	if t.deleted == nil {
+		return 0, fmt.Errorf("Restore error : 表%s对应的struct没有softdelete选项的成员", t.table)
+	}
+	where, err := o.whereByPK(t)
+	if err != nil {
+		return 0, fmt.Errorf("Restore error : %s", err.Error())
+	}
+	name := o.quoteIdent(t.deleted.name)
+	sql := "UPDATE " + t.table + " SET " + name + " = NULL WHERE " + where + " AND " + name + " IS NOT NULL"
+	params, err := o.columnParams(elem, t.pks...)
+	if err != nil {
+		return 0, fmt.Errorf("Restore error : %s", err.Error())
+	}
+	count, err := o.exec(logPrefix, sql, []interface{}{params})
+	if err == nil {
+		if v := structFieldValue(elem, t.deleted.field); v.IsValid() && v.CanSet() {
+			v.Set(reflect.Zero(v.Type()))
+		}
+	}
+	return count, err
+}
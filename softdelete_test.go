@@ -0,0 +1,130 @@
+package osm
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testSoftUser struct {
+	ID        int64      `db:"id,pk"`
+	Name      string     `db:"name"`
+	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+}
+
+func (testSoftUser) TableName() string { return "user" }
+
+func TestSoftDelete(t *testing.T) {
+	t.Run("delete sets timestamp", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `user` SET `deleted_at` = ? WHERE `id` = ? AND `deleted_at` IS NULL")).
+			ExpectExec().WithArgs(sqlmock.AnyArg(), int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		user := &testSoftUser{ID: 3}
+		count, err := o.DeleteByPK("", user)
+		if err != nil || count != 1 {
+			t.Fatalf("count=%d err=%v", count, err)
+		}
+		if user.DeletedAt == nil || user.DeletedAt.IsZero() {
+			t.Errorf("DeletedAt not filled: %v", user.DeletedAt)
+		}
+	})
+
+	t.Run("unix timestamp column", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		type post struct {
+			ID        int64 `db:"id,pk"`
+			DeletedAt int64 `db:"deleted_at,softdelete"`
+		}
+		mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `post` SET `deleted_at` = ? WHERE `id` = ? AND `deleted_at` IS NULL")).
+			ExpectExec().WithArgs(sqlmock.AnyArg(), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		p := &post{ID: 1}
+		if _, err := o.DeleteByPK("post", p); err != nil {
+			t.Fatal(err)
+		}
+		if p.DeletedAt == 0 {
+			t.Error("DeletedAt not filled")
+		}
+	})
+
+	t.Run("unscoped deletes row", func(t *testing.T) {
+		base, mock := newMockOsm(t)
+		o := &Osm{osmBase: *base}
+		mock.ExpectPrepare(regexp.QuoteMeta("DELETE FROM `user` WHERE `id` = ?")).
+			ExpectExec().WithArgs(int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if _, err := o.Unscoped().DeleteByPK("", testSoftUser{ID: 3}); err != nil {
+			t.Fatal(err)
+		}
+		if o.unscoped {
+			t.Error("Unscoped should not modify the original Osm")
+		}
+	})
+}
+
+func TestSoftDeleteFilter(t *testing.T) {
+	base, mock := newMockOsm(t)
+	o := &Osm{osmBase: *base}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, `name`, `deleted_at` FROM `user` WHERE `id` = ? AND `deleted_at` IS NULL")).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_at"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, `name`, `deleted_at` FROM `user` WHERE `id` = ?")).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_at"}).AddRow(int64(3), "a", time.Now()))
+	mock.ExpectPrepare(regexp.QuoteMeta("UPDATE `user` SET `name` = ? WHERE `id` = ? AND `deleted_at` IS NULL")).
+		ExpectExec().WithArgs("b", int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var user testSoftUser
+	if count, err := o.GetByPK("", &user, 3); err != nil || count != 0 {
+		t.Fatalf("count=%d err=%v", count, err)
+	}
+	if count, err := o.Unscoped().GetByPK("", &user, 3); err != nil || count != 1 || user.DeletedAt == nil {
+		t.Fatalf("count=%d err=%v user=%+v", count, err, user)
+	}
+	if _, err := o.UpdateStruct("", testSoftUser{ID: 3, Name: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	o, mock := newMockOsm(t)
+	o.dbType = dbTypePostgres
+	mock.ExpectPrepare(regexp.QuoteMeta(`UPDATE "user" SET "deleted_at" = NULL WHERE "id" = $1 AND "deleted_at" IS NOT NULL`)).
+		ExpectExec().WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	now := time.Now()
+	user := &testSoftUser{ID: 3, DeletedAt: &now}
+	count, err := o.Restore("", user)
+	if err != nil || count != 1 {
+		t.Fatalf("count=%d err=%v", count, err)
+	}
+	if user.DeletedAt != nil {
+		t.Errorf("DeletedAt = %v, want nil", user.DeletedAt)
+	}
+
+	if _, err := o.Restore("", &testCrudUser{ID: 3}); err == nil {
+		t.Error("expected error for struct without softdelete column")
+	}
+}
+
+func TestUnscopedNotClosable(t *testing.T) {
+	base, _ := newMockOsm(t)
+	o := &Osm{osmBase: *base}
+	if err := o.Unscoped().Close(); err == nil {
+		t.Error("expected error closing Unscoped() Osm")
+	}
+	if o.db == nil {
+		t.Error("closing Unscoped() Osm should not affect the original")
+	}
+}
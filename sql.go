@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -47,24 +46,7 @@ func (o *osmBase) replaceSQLPlaceholders(sql string) string {
 //
 // 删除id为1和2的用户数据
 func (o *osmBase) Delete(sql string, params ...interface{}) (int64, error) {
-	logPrefix := getCallerInfo(2)
-	defer o.slowLogDefer(logPrefix, sql, time.Now())()
-
-	sql, sqlParams, err := o.readSQLParamsBySQL(logPrefix, sql, params...)
-	if err != nil {
-		return 0, err
-	}
-	stmt, err := o.db.Prepare(sql)
-	if err != nil {
-		return 0, err
-	}
-	defer stmt.Close()
-
-	result, err := stmt.Exec(sqlParams...)
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected()
+	return o.exec(getCallerInfo(2), sql, params)
 }
 
 // Update 执行更新sql
@@ -83,20 +65,24 @@ func (o *osmBase) Delete(sql string, params ...interface{}) (int64, error) {
 //
 // 将id为1的用户email更新为"test2@foxmail.com"
 func (o *osmBase) Update(sql string, params ...interface{}) (int64, error) {
-	logPrefix := getCallerInfo(2)
+	return o.exec(getCallerInfo(2), sql, params)
+}
+
+// exec 执行sql，返回影响的行数，Delete、Update及InsertStruct等方法共用
+func (o *osmBase) exec(logPrefix, sql string, params []interface{}) (int64, error) {
 	defer o.slowLogDefer(logPrefix, sql, time.Now())()
 
 	sql, sqlParams, err := o.readSQLParamsBySQL(logPrefix, sql, params...)
 	if err != nil {
 		return 0, err
 	}
-	stmt, err := o.db.Prepare(sql)
+	stmt, err := o.db.PrepareContext(o.context(), sql)
 	if err != nil {
 		return 0, err
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(sqlParams...)
+	result, err := stmt.ExecContext(o.context(), sqlParams...)
 	if err != nil {
 		return 0, err
 	}
@@ -121,7 +107,7 @@ func (o *osmBase) UpdateMulti(sql string, params ...interface{}) error {
 	if err != nil {
 		return err
 	}
-	_, err = o.db.Exec(sql, sqlParams...)
+	_, err = o.db.ExecContext(o.context(), sql, sqlParams...)
 	return err
 }
 
@@ -144,26 +130,30 @@ func (o *osmBase) UpdateMulti(sql string, params ...interface{}) error {
 //
 // 添加一个用户数据，email为"test@foxmail.com"
 func (o *osmBase) Insert(sql string, params ...interface{}) (int64, int64, error) {
-	logPrefix := getCallerInfo(2)
+	return o.insert(getCallerInfo(2), sql, params)
+}
+
+// insert 执行添加sql，返回自增ID(MySQL、TiDB、SQLite)和影响的行数
+func (o *osmBase) insert(logPrefix, sql string, params []interface{}) (int64, int64, error) {
 	defer o.slowLogDefer(logPrefix, sql, time.Now())()
 
 	sql, sqlParams, err := o.readSQLParamsBySQL(logPrefix, sql, params...)
 	if err != nil {
 		return 0, 0, err
 	}
-	stmt, err := o.db.Prepare(sql)
+	stmt, err := o.db.PrepareContext(o.context(), sql)
 	if err != nil {
 		return 0, 0, err
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(sqlParams...)
+	result, err := stmt.ExecContext(o.context(), sqlParams...)
 	if err != nil {
 		return 0, 0, err
 	}
 
 	var insertID int64
-	if o.dbType == dbTypeMysql {
+	if o.dbType == dbTypeMysql || o.dbType == dbTypeTiDB || o.dbType == dbTypeSqlite {
 		insertID, err = result.LastInsertId()
 		if err != nil {
 			o.options.ErrorLogger.Log(logPrefix+"lastInsertId read error", map[string]string{"error": err.Error()})
@@ -349,6 +339,16 @@ func (o *osmBase) readSQLParamsBySQL(logPrefix, sqlOrg string, params ...interfa
 	// 先替换SQL占位符
 	sqlOrg = o.replaceSQLPlaceholders(sqlOrg)
 
+	// 替换${name}为参数中的标识符，之后没有#{}时参数只用于标识符；字符串、引号和注释中的${不处理
+	if codeIndex(sqlOrg, "${") >= 0 {
+		if sqlOrg, err = o.expandIdents(sqlOrg, params); err != nil {
+			return
+		}
+		if !strings.Contains(sqlOrg, "#{") {
+			params = nil
+		}
+	}
+
 	// 检测是否使用原生SQL占位符（MySQL的?或PostgreSQL的$1,$2等）
 	// 只要不包含 Named 参数标记 #{，就认为是原生 SQL
 	// 原生占位符模式，直接使用传入的参数，不进行Named参数解析
@@ -415,6 +415,16 @@ func (o *osmBase) readSQLParamsBySQL(logPrefix, sqlOrg string, params ...interfa
 					content: strings.TrimSpace(sqlTemp[0:ei]),
 					isParam: true,
 					isIn:    sqlIsIn(lastSQLText),
+					isArray: sqlIsArray(lastSQLText),
+				}
+				// #{q|contains}等LIKE修饰符
+				if name, like, ok := strings.Cut(pni.content, "|"); ok {
+					pni.content, pni.like = strings.TrimSpace(name), strings.TrimSpace(like)
+					last := sqls[len(sqls)-1]
+					if last.content, err = o.likeKeyword(last.content, pni.like); err != nil {
+						err = fmt.Errorf("sql '%s' error : #{%s} : %s", sqlOrg, sqlTemp[0:ei], err.Error())
+						return
+					}
 				}
 				sqls = append(sqls, pni)
 				paramNames = append(paramNames, pni)
@@ -435,13 +445,19 @@ func (o *osmBase) readSQLParamsBySQL(logPrefix, sqlOrg string, params ...interfa
 		kind := v.Kind()
 		switch {
 		case kind == reflect.Array || kind == reflect.Slice:
-			if len(paramNames) == 1 && paramNames[0].isIn {
-				setDataToParamName(paramNames[0], v)
+			if len(paramNames) == 1 && (paramNames[0].isIn || (paramNames[0].isArray && o.isArrayDB())) {
+				if err = o.setDataToParamName(paramNames[0], v); err != nil {
+					err = fmt.Errorf("sql '%s' error : %s", sqlOrg, err.Error())
+					return
+				}
 			} else {
 				for i := 0; i < v.Len() && i < len(paramNames); i++ {
 					vv := v.Index(i)
 					if vv.IsValid() {
-						setDataToParamName(paramNames[i], v.Index(i))
+						if err = o.setDataToParamName(paramNames[i], v.Index(i)); err != nil {
+							err = fmt.Errorf("sql '%s' error : %s", sqlOrg, err.Error())
+							return
+						}
 					}
 				}
 			}
@@ -449,7 +465,10 @@ func (o *osmBase) readSQLParamsBySQL(logPrefix, sqlOrg string, params ...interfa
 			for _, paramName := range paramNames {
 				vv := v.MapIndex(reflect.ValueOf(paramName.content))
 				if vv.IsValid() {
-					setDataToParamName(paramName, vv)
+					if err = o.setDataToParamName(paramName, vv); err != nil {
+						err = fmt.Errorf("sql '%s' error : %s", sqlOrg, err.Error())
+						return
+					}
 				} else {
 					err = fmt.Errorf("sql '%s' error : Key '%s' no exist", sqlOrg, paramName.content)
 					return
@@ -458,25 +477,40 @@ func (o *osmBase) readSQLParamsBySQL(logPrefix, sqlOrg string, params ...interfa
 		case kind == reflect.Struct:
 			tagMap := map[string]*structFieldInfo{}
 			nameMap := map[string]*structFieldInfo{}
-			getStructFieldMap(v.Type(), tagMap, nameMap, false)
+			if err = getStructFieldMap(v.Type(), tagMap, nameMap); err != nil {
+				err = fmt.Errorf("sql '%s' error : %s", sqlOrg, err.Error())
+				return
+			}
 
 			for _, paramName := range paramNames {
 				var vv reflect.Value
-				if field, ok := tagMap[paramName.content]; ok {
-					if field.a {
-						vv = v.FieldByName(field.n)
-					} else {
-						vv = v.Field(field.i)
-					}
-				} else if field, ok := nameMap[paramName.content]; ok {
-					if field.a {
-						vv = v.FieldByName(field.n)
-					} else {
-						vv = v.Field(field.i)
+				field, ok := nameMap[paramName.content]
+				if tagField, tagOk := tagMap[paramName.content]; tagOk {
+					field, ok = tagField, true
+				}
+				if !ok {
+					// 如#{user_name}，按NameMapper查找成员
+					field, err = findFieldBy(o.options.nameMapper(), tagMap, nameMap, paramName.content)
+				} else {
+					field, err = field.checkAmbiguous(paramName.content)
+				}
+				if err != nil {
+					err = fmt.Errorf("sql '%s' error : %s", sqlOrg, err.Error())
+					return
+				}
+				if field != nil {
+					vv = structFieldValue(v, field)
+					if !vv.IsValid() {
+						// 嵌入的struct指针为nil，绑定为NULL
+						vv = reflect.Zero(reflect.PointerTo(*field.t))
 					}
+					paramName.isJSON = field.json
 				}
 				if vv.IsValid() {
-					setDataToParamName(paramName, vv)
+					if err = o.setDataToParamName(paramName, vv); err != nil {
+						err = fmt.Errorf("sql '%s' error : %s", sqlOrg, err.Error())
+						return
+					}
 				} else {
 					err = fmt.Errorf("sql '%s' error : Field '%s' no exist", sqlOrg, paramName.content)
 					return
@@ -500,7 +534,10 @@ func (o *osmBase) readSQLParamsBySQL(logPrefix, sqlOrg string, params ...interfa
 			kind == reflect.Complex128 ||
 			kind == reflect.String:
 			for _, paramName := range paramNames {
-				setDataToParamName(paramName, v)
+				if err = o.setDataToParamName(paramName, v); err != nil {
+					err = fmt.Errorf("sql '%s' error : %s", sqlOrg, err.Error())
+					return
+				}
 			}
 		default:
 		}
@@ -515,34 +552,22 @@ func (o *osmBase) readSQLParamsBySQL(logPrefix, sqlOrg string, params ...interfa
 						if index > 0 {
 							sqlTexts = append(sqlTexts, ",")
 						}
-						var placeholder string
-						switch o.dbType {
-						case dbTypeMysql, dbTypeSqlite, dbTypeTiDB:
-							placeholder = "?"
-						case dbTypeOracle:
-							placeholder = ":" + strconv.Itoa(signIndex)
-							signIndex++
-						default: // PostgreSQL, MSSQL, CockroachDB, ClickHouse
-							placeholder = "$" + strconv.Itoa(signIndex)
-							signIndex++
-						}
-						sqlTexts = append(sqlTexts, placeholder)
+						sqlTexts = append(sqlTexts, o.placeholder(signIndex))
+						signIndex++
 						sqlParams = append(sqlParams, pv)
 					}
 					sqlTexts = append(sqlTexts, ")")
-				} else {
-					var placeholder string
-					switch o.dbType {
-					case dbTypeMysql, dbTypeSqlite, dbTypeTiDB:
-						placeholder = "?"
-					case dbTypeOracle:
-						placeholder = ":" + strconv.Itoa(signIndex)
-						signIndex++
-					default: // PostgreSQL, MSSQL, CockroachDB, ClickHouse
-						placeholder = "$" + strconv.Itoa(signIndex)
-						signIndex++
+				} else if sql.like != "" {
+					pattern, likeErr := o.likePattern(sql.like, sql.paramValue)
+					if likeErr != nil {
+						return "", nil, fmt.Errorf("sql '%s' error : #{%s|%s} : %s", sqlOrg, sql.content, sql.like, likeErr.Error())
 					}
-					sqlTexts = append(sqlTexts, placeholder)
+					sqlTexts = append(sqlTexts, o.placeholder(signIndex)+o.likeEscapeClause())
+					signIndex++
+					sqlParams = append(sqlParams, pattern)
+				} else {
+					sqlTexts = append(sqlTexts, o.placeholder(signIndex))
+					signIndex++
 					sqlParams = append(sqlParams, sql.paramValue)
 				}
 			} else {
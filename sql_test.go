@@ -702,3 +702,19 @@ func TestSQLIsIn(t *testing.T) {
 		})
 	}
 }
+
+func TestReadSQLParamsBySQLExcludedField(t *testing.T) {
+	type user struct {
+		ID       int64  `db:"id"`
+		Password string `db:"-"`
+	}
+	o := &osmBase{dbType: dbTypeMysql, options: &Options{}}
+	o.options.tidy()
+
+	if _, _, err := o.readSQLParamsBySQL("", "SELECT * FROM user WHERE id = #{id}", user{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := o.readSQLParamsBySQL("", "UPDATE user SET password = #{Password}", user{Password: "x"}); err == nil {
+		t.Fatal("expected error for excluded field")
+	}
+}
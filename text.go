@@ -0,0 +1,93 @@
+package osm
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	valuerType          = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// isTextDest 目标类型是否通过UnmarshalText读取，time.Time和big.Int等数值使用内置规则
+func isTextDest(destType reflect.Type) bool {
+	return destType != timeType && !isBigNumberType(destType) &&
+		reflect.PointerTo(destType).Implements(textUnmarshalerType)
+}
+
+// scanText 通过UnmarshalText读取字符串列，如状态值'active'读取到type Status int
+//
+// UnmarshalText失败时，如果目标是数值类型且内容为数字则按数值读取；
+// Options.StrictEnums为true时，UnmarshalText失败或MarshalText不能还原原值都会返回错误。
+func (o *osmBase) scanText(logPrefix string, dest reflect.Value, text []byte, destIsPtr bool, destType reflect.Type) error {
+	data := reflect.New(destType)
+	err := data.Interface().(encoding.TextUnmarshaler).UnmarshalText(text)
+	if err != nil && isNumberKind(destType.Kind()) {
+		if _, ok := new(big.Rat).SetString(strings.TrimSpace(string(text))); ok {
+			return o.convertNumber(logPrefix, dest, text, destIsPtr, destType)
+		}
+	}
+	if err == nil && o.options.StrictEnums {
+		var m encoding.TextMarshaler
+		if destType.Implements(textMarshalerType) {
+			m = data.Elem().Interface().(encoding.TextMarshaler)
+		} else if data.Type().Implements(textMarshalerType) {
+			m = data.Interface().(encoding.TextMarshaler)
+		}
+		if m != nil {
+			if out, merr := m.MarshalText(); merr != nil || !bytes.Equal(out, text) {
+				err = fmt.Errorf("unknown label %q", text)
+			}
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("converting %q to a %s: %s", text, destType, err.Error())
+		if o.options.StrictEnums {
+			return err
+		}
+		o.options.WarnLogger.Log(logPrefix+"convertAssign Text error", map[string]string{"error": err.Error()})
+		return nil
+	}
+	setValue(destIsPtr, dest, data.Elem().Interface(), destType)
+	return nil
+}
+
+// bindText 实现了encoding.TextMarshaler的参数绑定为MarshalText的结果，实现了driver.Valuer的除外
+func bindText(v reflect.Value) (interface{}, bool, error) {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false, nil
+		}
+		v = v.Elem()
+	}
+	t := v.Type()
+	if t == timeType || t.Implements(valuerType) || reflect.PointerTo(t).Implements(valuerType) {
+		return nil, false, nil
+	}
+	var m encoding.TextMarshaler
+	switch {
+	case t.Implements(textMarshalerType):
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, true, nil
+		}
+		m = v.Interface().(encoding.TextMarshaler)
+	case reflect.PointerTo(t).Implements(textMarshalerType):
+		data := reflect.New(t)
+		data.Elem().Set(v)
+		m = data.Interface().(encoding.TextMarshaler)
+	default:
+		return nil, false, nil
+	}
+	text, err := m.MarshalText()
+	if err != nil {
+		return nil, true, err
+	}
+	return string(text), true, nil
+}
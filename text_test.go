@@ -0,0 +1,139 @@
+package osm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testStatus int
+
+const (
+	testStatusActive testStatus = iota + 1
+	testStatusBanned
+)
+
+var testStatusNames = map[testStatus]string{testStatusActive: "active", testStatusBanned: "banned"}
+
+func (s testStatus) MarshalText() ([]byte, error) {
+	if name, ok := testStatusNames[s]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("invalid status %d", int(s))
+}
+
+func (s *testStatus) UnmarshalText(text []byte) error {
+	for k, v := range testStatusNames {
+		if v == string(text) {
+			*s = k
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid status %q", text)
+}
+
+// testLenientLevel 未知值时不报错，UnmarshalText总是成功
+type testLenientLevel int
+
+func (l testLenientLevel) MarshalText() ([]byte, error) {
+	if l == 1 {
+		return []byte("high"), nil
+	}
+	return []byte("low"), nil
+}
+
+func (l *testLenientLevel) UnmarshalText(text []byte) error {
+	if string(text) == "high" {
+		*l = 1
+	} else {
+		*l = 0
+	}
+	return nil
+}
+
+func TestTextScan(t *testing.T) {
+	type account struct {
+		ID     int64       `db:"id"`
+		Status testStatus  `db:"status"`
+		Prev   *testStatus `db:"prev"`
+	}
+	o, mock := newMockOsm(t)
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "status", "prev"}).
+			AddRow(1, []byte("banned"), "active").
+			AddRow(2, []byte("2"), nil),
+	)
+
+	var accounts []account
+	if _, err := o.SelectStructs("SELECT id, status, prev FROM account")(&accounts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if accounts[0].Status != testStatusBanned || accounts[0].Prev == nil || *accounts[0].Prev != testStatusActive {
+		t.Errorf("accounts[0] = %+v", accounts[0])
+	}
+	if accounts[1].Status != testStatusBanned {
+		t.Errorf("numeric fallback: Status = %v", accounts[1].Status)
+	}
+}
+
+func TestTextScanStrict(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool
+		dest   interface{}
+		src    interface{}
+		want   interface{}
+		errMsg string
+	}{
+		{"unknown label lenient", false, testStatus(0), []byte("deleted"), testStatus(0), ""},
+		{"unknown label strict", true, testStatus(0), []byte("deleted"), nil, "invalid status"},
+		{"no round trip lenient", false, testLenientLevel(0), "medium", testLenientLevel(0), ""},
+		{"no round trip strict", true, testLenientLevel(0), "medium", nil, "unknown label"},
+		{"known label strict", true, testLenientLevel(0), "high", testLenientLevel(1), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, _ := newMockOsm(t)
+			o.options.StrictEnums = tt.strict
+			destType := reflect.TypeOf(tt.dest)
+			dest := reflect.New(destType).Elem()
+			err := o.convertAssign("test", dest, tt.src, false, destType)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if dest.Interface() != tt.want {
+				t.Errorf("got %v, want %v", dest.Interface(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTextBind(t *testing.T) {
+	o, _ := newMockOsm(t)
+	var nilStatus *testStatus
+	_, params, err := o.readSQLParamsBySQL("test", "UPDATE account SET status=#{Status}, prev=#{Prev} WHERE status IN #{In}", map[string]interface{}{
+		"Status": testStatusBanned,
+		"Prev":   nilStatus,
+		"In":     []testStatus{testStatusActive, testStatusBanned},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(params, []interface{}{"banned", nil, "active", "banned"}) {
+		t.Errorf("params = %#v", params)
+	}
+
+	_, _, err = o.readSQLParamsBySQL("test", "UPDATE account SET status=#{Status}", map[string]interface{}{"Status": testStatus(9)})
+	if err == nil || !strings.Contains(err.Error(), "invalid status") {
+		t.Errorf("expected MarshalText error, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package osm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeBindMode time.Time参数的绑定方式，通过Options.BindTimeAs设置
+type TimeBindMode int
+
+const (
+	// BindTimeDefault 默认方式，struct成员和单个参数格式化为"2006-01-02 15:04:05"，map中的值原样传给driver
+	BindTimeDefault TimeBindMode = iota
+	// BindTimeNative 原样传给driver，保留纳秒和时区
+	BindTimeNative
+	// BindTimeString 按Options.TimeFormat格式化为字符串
+	BindTimeString
+	// BindTimeUnix 转为Unix时间戳(秒)
+	BindTimeUnix
+)
+
+// defaultTimeLayouts 解析不带时区的时间字符串时依次尝试的格式
+var defaultTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	formatDate,
+}
+
+// timeLocation 读取时间时使用的时区，默认为time.Local
+func (options *Options) timeLocation() *time.Location {
+	if options.TimeLocation == nil {
+		return time.Local
+	}
+	return options.TimeLocation
+}
+
+// bindTime 按BindTimeAs转换time.Time参数，fromMap表示参数来自map或interface{}
+func (options *Options) bindTime(t time.Time, fromMap bool) interface{} {
+	switch options.BindTimeAs {
+	case BindTimeNative:
+		return t
+	case BindTimeUnix:
+		return t.Unix()
+	case BindTimeDefault:
+		if fromMap {
+			return t
+		}
+	}
+	if options.TimeLocation != nil {
+		t = t.In(options.TimeLocation)
+	}
+	format := options.TimeFormat
+	if format == "" {
+		format = formatDateTime
+	}
+	return timeFormat(t, format)
+}
+
+// parseTime 解析时间字符串，先尝试Options.TimeLayouts，再尝试内置格式，不带时区的按TimeLocation解析
+func (options *Options) parseTime(str string) (time.Time, error) {
+	loc := options.timeLocation()
+	str = strings.TrimSpace(str)
+	for _, layout := range options.TimeLayouts {
+		if t, err := time.ParseInLocation(layout, str, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	for _, layout := range defaultTimeLayouts {
+		if t, err := time.ParseInLocation(layout, str, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	// 兼容其他以日期时间开头的格式，如"2006-01-02 15:04:05 +0800 CST"
+	var t time.Time
+	var err error
+	if len(str) >= 19 {
+		t, err = time.ParseInLocation(formatDateTime, str[:19], loc)
+	} else if len(str) >= 10 {
+		t, err = time.ParseInLocation(formatDate, str[:10], loc)
+	} else {
+		err = fmt.Errorf("cannot parse %q as time", str)
+	}
+	if err != nil {
+		return t, err
+	}
+	return t.In(loc), nil
+}
+
+// Date 不带时间和时区的日期，对应DATE列，绑定为"2006-01-02"
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf 取t在其时区中的日期
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// ParseDate 解析"2006-01-02"格式的日期
+func ParseDate(s string) (Date, error) {
+	if len(s) > 10 {
+		s = s[:10]
+	}
+	t, err := time.Parse(formatDate, s)
+	if err != nil {
+		return Date{}, err
+	}
+	return DateOf(t), nil
+}
+
+// In 返回该日期在loc中的零点
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// IsZero 是否为零值
+func (d Date) IsZero() bool {
+	return d.Year == 0 && d.Month == 0 && d.Day == 0
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, int(d.Month), d.Day)
+}
+
+// Value 实现driver.Valuer
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan 实现sql.Scanner，time.Time取其所在时区的日期，不做时区转换
+func (d *Date) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = DateOf(s)
+	case string:
+		date, err := ParseDate(s)
+		if err != nil {
+			return err
+		}
+		*d = date
+	case []byte:
+		date, err := ParseDate(string(s))
+		if err != nil {
+			return err
+		}
+		*d = date
+	default:
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+	return nil
+}
+
+// TimeOfDay 不带日期和时区的时间，对应TIME列，绑定为"15:04:05"，有纳秒时带小数部分
+type TimeOfDay struct {
+	Hour       int
+	Minute     int
+	Second     int
+	Nanosecond int
+}
+
+// TimeOfDayOf 取t在其时区中的时间
+func TimeOfDayOf(t time.Time) TimeOfDay {
+	return TimeOfDay{Hour: t.Hour(), Minute: t.Minute(), Second: t.Second(), Nanosecond: t.Nanosecond()}
+}
+
+// ParseTimeOfDay 解析"15:04:05"格式的时间，可带小数秒
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	t, err := time.Parse("15:04:05.999999999", strings.TrimSpace(s))
+	if err != nil {
+		return TimeOfDay{}, err
+	}
+	return TimeOfDayOf(t), nil
+}
+
+func (t TimeOfDay) String() string {
+	s := fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+	if t.Nanosecond != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%09d", t.Nanosecond), "0")
+	}
+	return s
+}
+
+// Value 实现driver.Valuer
+func (t TimeOfDay) Value() (driver.Value, error) {
+	return t.String(), nil
+}
+
+// Scan 实现sql.Scanner，time.Time取其所在时区的时间，不做时区转换
+func (t *TimeOfDay) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case nil:
+		*t = TimeOfDay{}
+	case time.Time:
+		*t = TimeOfDayOf(s)
+	case string:
+		tod, err := ParseTimeOfDay(s)
+		if err != nil {
+			return err
+		}
+		*t = tod
+	case []byte:
+		tod, err := ParseTimeOfDay(string(s))
+		if err != nil {
+			return err
+		}
+		*t = tod
+	default:
+		return fmt.Errorf("cannot scan %T into TimeOfDay", src)
+	}
+	return nil
+}
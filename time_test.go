@@ -0,0 +1,128 @@
+package osm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBindTimeAs(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	ts := time.Date(2024, 6, 15, 10, 30, 0, 123456789, time.UTC)
+	tests := []struct {
+		name    string
+		options Options
+		param   interface{}
+		want    interface{}
+	}{
+		{"default scalar", Options{}, []time.Time{ts}, "2024-06-15 10:30:00"},
+		{"default map", Options{}, map[string]interface{}{"T": ts}, ts},
+		{"native", Options{BindTimeAs: BindTimeNative}, struct{ T time.Time }{ts}, ts},
+		{"unix", Options{BindTimeAs: BindTimeUnix}, map[string]interface{}{"T": ts}, ts.Unix()},
+		{"string with location", Options{BindTimeAs: BindTimeString, TimeLocation: shanghai}, struct{ T time.Time }{ts}, "2024-06-15 18:30:00"},
+		{"string with format", Options{BindTimeAs: BindTimeString, TimeFormat: time.RFC3339Nano}, struct{ T time.Time }{ts}, "2024-06-15T10:30:00.123456789Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.options
+			opts.tidy()
+			o := &osmBase{dbType: dbTypeMysql, options: &opts}
+			_, params, err := o.readSQLParamsBySQL("test", "SELECT * FROM t WHERE created = #{T}", tt.param)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(params) != 1 || !reflect.DeepEqual(params[0], tt.want) {
+				t.Errorf("got %#v, want %#v", params, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeLocation(t *testing.T) {
+	opts := &Options{TimeLocation: time.UTC}
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-06-15 10:30:00", time.Date(2024, 6, 15, 10, 30, 0, 0, time.UTC)},
+		{"2024-06-15 10:30:00.5", time.Date(2024, 6, 15, 10, 30, 0, 500000000, time.UTC)},
+		{"2024-06-15T10:30:00+08:00", time.Date(2024, 6, 15, 2, 30, 0, 0, time.UTC)},
+		{"2024-06-15 10:30:00+08", time.Date(2024, 6, 15, 2, 30, 0, 0, time.UTC)},
+		{"2024-06-15", time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := opts.parseTime(tt.in)
+		if err != nil {
+			t.Errorf("parseTime(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("parseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	opts.TimeLayouts = []string{"02/01/2006"}
+	got, err := opts.parseTime("15/06/2024")
+	if err != nil || !got.Equal(time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("custom layout: got %v, %v", got, err)
+	}
+}
+
+func TestScanTimeTypes(t *testing.T) {
+	o, mock := newMockOsm(t)
+	o.options.TimeLocation = time.UTC
+
+	type event struct {
+		At      time.Time  `db:"at"`
+		Created *time.Time `db:"created"`
+		Day     Date       `db:"day"`
+		Start   TimeOfDay  `db:"start"`
+		End     *TimeOfDay `db:"end"`
+		Unix    time.Time  `db:"unix"`
+	}
+	at := time.Date(2024, 6, 15, 18, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"at", "created", "day", "start", "end", "unix"}).
+			AddRow(at, []byte("2024-06-15 10:30:00.25"), time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC), "09:15:30.5", nil, int64(1718447400)),
+	)
+
+	var e event
+	if _, err := o.SelectStruct("SELECT * FROM events")(&e); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.At.Location() != time.UTC || !e.At.Equal(at) {
+		t.Errorf("At = %v", e.At)
+	}
+	if e.Created == nil || !e.Created.Equal(time.Date(2024, 6, 15, 10, 30, 0, 250000000, time.UTC)) {
+		t.Errorf("Created = %v", e.Created)
+	}
+	if e.Day != (Date{2024, time.June, 15}) {
+		t.Errorf("Day = %v", e.Day)
+	}
+	if e.Start != (TimeOfDay{9, 15, 30, 500000000}) {
+		t.Errorf("Start = %v", e.Start)
+	}
+	if e.End != nil {
+		t.Errorf("End = %v, want nil", e.End)
+	}
+	if !e.Unix.Equal(time.Unix(1718447400, 0)) {
+		t.Errorf("Unix = %v", e.Unix)
+	}
+}
+
+func TestDateAndTimeOfDayValue(t *testing.T) {
+	if v, _ := (Date{2024, time.January, 2}).Value(); v != "2024-01-02" {
+		t.Errorf("Date.Value() = %v", v)
+	}
+	if v, _ := (TimeOfDay{Hour: 8, Minute: 5}).Value(); v != "08:05:00" {
+		t.Errorf("TimeOfDay.Value() = %v", v)
+	}
+	if v, _ := (TimeOfDay{Hour: 8, Minute: 5, Nanosecond: 120000000}).Value(); v != "08:05:00.12" {
+		t.Errorf("TimeOfDay.Value() = %v", v)
+	}
+	if _, err := ParseDate("2024-13-01"); err == nil {
+		t.Error("expected error for invalid date")
+	}
+}
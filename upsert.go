@@ -0,0 +1,375 @@
+package osm
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// defaultBatchSize Options.BatchSize的默认值
+const defaultBatchSize = 500
+
+// UpsertResult Upsert的结果
+type UpsertResult struct {
+	// Affected driver返回的影响行数，MySQL中更新的行计为2
+	Affected int64
+	// Inserted 新增的行数，数据库不能区分新增和更新时为-1
+	Inserted int64
+	// Updated 更新的行数，数据库不能区分新增和更新时为-1
+	Updated int64
+}
+
+// add 合并分批执行的结果
+func (r *UpsertResult) add(chunk UpsertResult) {
+	r.Affected += chunk.Affected
+	if r.Inserted < 0 || chunk.Inserted < 0 {
+		r.Inserted, r.Updated = -1, -1
+		return
+	}
+	r.Inserted += chunk.Inserted
+	r.Updated += chunk.Updated
+}
+
+// Upsert 写入一行或多行，冲突时更新，table为空时使用TableName()
+//
+// data为struct、struct的指针或它们的切片，多行时按Options.BatchSize分批执行，执行前对每行调用BeforeInsert。
+// conflictColumns为判断冲突的列(唯一索引)，为空时使用pk的列；
+// updateColumns为冲突时更新的列，为空时更新冲突列和pk以外的全部列，列名可以是列名或成员名。
+// 写入readonly和softdelete以外的列，auto的列只在作为冲突列时写入；更新时version选项的列加1，
+// 不更新autoCreateTime和autoActor的列，总会更新autoUpdateTime的列。
+//
+// 生成的sql：MySQL、TiDB为ON DUPLICATE KEY UPDATE col = VALUES(col)(Options.MysqlRowAlias为true时MySQL使用行别名)，
+// PostgreSQL、CockroachDB、SQLite为ON CONFLICT，
+// MSSQL、Oracle为MERGE。PostgreSQL和MSSQL会返回新增和更新的行数，MySQL只有单行时可以区分，其他为-1。
+//
+// 代码
+//
+//	result, err := o.Upsert("", []string{"email"}, []string{"nickname"}, users)
+//	log.Println(result.Inserted, result.Updated)
+func (o *osmBase) Upsert(table string, conflictColumns, updateColumns []string, data interface{}) (UpsertResult, error) {
+	return o.upsert(getCallerInfo(2), table, conflictColumns, updateColumns, data)
+}
+
+func (o *osmBase) upsert(logPrefix, table string, conflictColumns, updateColumns []string, data interface{}) (UpsertResult, error) {
+	result := UpsertResult{}
+	rows, structType, err := upsertRows(data)
+	if err != nil {
+		return result, fmt.Errorf("Upsert error : %s", err.Error())
+	}
+	if len(rows) == 0 {
+		return result, nil
+	}
+	t, err := o.crudTableOf(table, structType)
+	if err != nil {
+		return result, fmt.Errorf("Upsert error : %s", err.Error())
+	}
+	conflicts, inserts, updates, err := t.upsertColumns(conflictColumns, updateColumns)
+	if err != nil {
+		return result, fmt.Errorf("Upsert error : %s", err.Error())
+	}
+	for i := range rows {
+		if rows[i], err = o.beforeInsert(rows[i]); err != nil {
+			return result, fmt.Errorf("Upsert error : BeforeInsert : %w", err)
+		}
+		if rows[i], err = o.fillAudit(logPrefix, t, rows[i], true); err != nil {
+			return result, fmt.Errorf("Upsert error : %s", err.Error())
+		}
+	}
+
+	if len(inserts) == 0 {
+		return result, fmt.Errorf("Upsert error : 表%s没有可写入的列", t.table)
+	}
+
+	batchSize := o.options.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if limit := (o.maxParams() - 1) / len(inserts); limit < batchSize {
+		batchSize = limit
+	}
+	for start := 0; start < len(rows); start += batchSize {
+		end := start + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		chunk, err := o.upsertChunk(logPrefix, t, conflicts, inserts, updates, rows[start:end])
+		if err != nil {
+			return result, err
+		}
+		result.add(chunk)
+	}
+	return result, nil
+}
+
+// upsertRows 取得data中的各行
+func upsertRows(data interface{}) ([]reflect.Value, reflect.Type, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Ptr && !v.IsNil() && (v.Elem().Kind() == reflect.Slice || v.Elem().Kind() == reflect.Array) {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		elem, err := structOf(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []reflect.Value{elem}, elem.Type(), nil
+	}
+	structType := v.Type().Elem()
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	rows := make([]reflect.Value, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem, err := structOf(v.Index(i).Interface())
+		if err != nil {
+			return nil, nil, fmt.Errorf("第%d行 : %s", i+1, err.Error())
+		}
+		rows = append(rows, elem)
+	}
+	return rows, structType, nil
+}
+
+// upsertColumns 确定冲突列、写入列和更新列
+func (t *crudTable) upsertColumns(conflictColumns, updateColumns []string) (conflicts, inserts, updates []*crudColumn, err error) {
+	if len(conflictColumns) == 0 {
+		if len(t.pks) == 0 {
+			return nil, nil, nil, fmt.Errorf("表%s没有指定冲突列，也没有pk选项的成员", t.table)
+		}
+		conflicts = t.pks
+	}
+	for _, name := range conflictColumns {
+		c := t.column(name)
+		if c == nil {
+			return nil, nil, nil, fmt.Errorf("表%s没有列'%s'", t.table, name)
+		}
+		conflicts = append(conflicts, c)
+	}
+	isConflict := func(c *crudColumn) bool {
+		return containsColumn(conflicts, c)
+	}
+
+	for _, c := range t.columns {
+		if c.field.readonly || c.field.softDelete || (c.field.auto && !isConflict(c)) {
+			continue
+		}
+		inserts = append(inserts, c)
+	}
+	for _, c := range conflicts {
+		if c.field.readonly {
+			return nil, nil, nil, fmt.Errorf("冲突列'%s'是readonly", c.name)
+		}
+	}
+
+	if len(updateColumns) == 0 {
+		for _, c := range inserts {
+			if !c.field.pk && !c.field.version && !c.field.createTime && !c.field.actor && !isConflict(c) {
+				updates = append(updates, c)
+			}
+		}
+		return
+	}
+	for _, name := range updateColumns {
+		c := t.column(name)
+		if c == nil {
+			return nil, nil, nil, fmt.Errorf("表%s没有列'%s'", t.table, name)
+		}
+		if c.field.readonly || c.field.version || isConflict(c) {
+			return nil, nil, nil, fmt.Errorf("列'%s'是冲突列、readonly或version，不能更新", name)
+		}
+		updates = append(updates, c)
+	}
+	// autoUpdateTime的列总会更新
+	for _, c := range inserts {
+		if c.field.updateTime && !isConflict(c) && !containsColumn(updates, c) {
+			updates = append(updates, c)
+		}
+	}
+	return
+}
+
+func containsColumn(cs []*crudColumn, c *crudColumn) bool {
+	for _, item := range cs {
+		if item == c {
+			return true
+		}
+	}
+	return false
+}
+
+// maxParams 一条sql中参数数量的上限
+func (o *osmBase) maxParams() int {
+	switch o.dbType {
+	case dbTypeMssql:
+		return 2100
+	case dbTypeSqlite:
+		return 32766
+	}
+	return 65535
+}
+
+// columnValue 取得行中成员的值作为参数，json选项的成员转为JSON，嵌入的struct指针为nil时为NULL
+func (o *osmBase) columnValue(elem reflect.Value, field *structFieldInfo) (interface{}, error) {
+	v := structFieldValue(elem, field)
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if field.json || isJSONType(v.Type()) {
+		return bindJSON(v)
+	}
+	return o.bindValue(v)
+}
+
+func (o *osmBase) upsertChunk(logPrefix string, t *crudTable, conflicts, inserts, updates []*crudColumn, rows []reflect.Value) (UpsertResult, error) {
+	params := make(map[string]interface{}, len(rows)*len(inserts))
+	tuples := make([]string, len(rows))
+	for i, row := range rows {
+		names := make([]string, len(inserts))
+		for j, c := range inserts {
+			name := "r" + strconv.Itoa(i) + "_" + strconv.Itoa(j)
+			value, err := o.columnValue(row, c.field)
+			if err != nil {
+				return UpsertResult{}, fmt.Errorf("Upsert error : 列'%s' : %s", c.name, err.Error())
+			}
+			params[name] = value
+			names[j] = "#{" + name + "}"
+		}
+		tuples[i] = "(" + strings.Join(names, ", ") + ")"
+	}
+	quote := func(cs []*crudColumn, format string) string {
+		parts := make([]string, len(cs))
+		for i, c := range cs {
+			parts[i] = strings.ReplaceAll(format, "%s", o.quoteIdent(c.name))
+		}
+		return strings.Join(parts, ", ")
+	}
+	// version选项的列在更新时加1，prefix为引用原值时的限定
+	versionSet := func(prefix string) string {
+		if t.version == nil || len(updates) == 0 {
+			return ""
+		}
+		name := o.quoteIdent(t.version.name)
+		return ", " + prefix + name + " = " + prefix + name + " + 1"
+	}
+	columns := quote(inserts, "%s")
+	values := strings.Join(tuples, ", ")
+	insert := "INSERT INTO " + t.table + " (" + columns + ") VALUES " + values
+
+	var sql string
+	switch o.dbType {
+	case dbTypeMysql, dbTypeTiDB:
+		set := quote(updates, "%s = VALUES(%s)")
+		if o.dbType == dbTypeMysql && o.options.MysqlRowAlias {
+			insert += " AS osm_new"
+			set = quote(updates, "%s = osm_new.%s")
+		}
+		set += versionSet("")
+		if len(updates) == 0 {
+			// 没有更新列时保持原值
+			set = quote(conflicts[:1], "%s = %s")
+		}
+		sql = insert + " ON DUPLICATE KEY UPDATE " + set
+		affected, err := o.exec(logPrefix, sql, []interface{}{params})
+		if err != nil {
+			return UpsertResult{}, err
+		}
+		result := UpsertResult{Affected: affected, Inserted: -1, Updated: -1}
+		if len(rows) == 1 {
+			// 单行时新增影响1行，更新影响2行，未变化影响0行
+			result.Inserted, result.Updated = 0, 0
+			switch affected {
+			case 1:
+				result.Inserted = 1
+			case 2:
+				result.Updated = 1
+			}
+		}
+		return result, nil
+
+	case dbTypePostgres, dbTypeCockroach, dbTypeSqlite:
+		sql = insert + " ON CONFLICT (" + quote(conflicts, "%s") + ") "
+		if len(updates) == 0 {
+			sql += "DO NOTHING"
+		} else {
+			sql += "DO UPDATE SET " + quote(updates, "%s = EXCLUDED.%s")
+			if t.version != nil {
+				name := o.quoteIdent(t.version.name)
+				sql += ", " + name + " = " + t.table + "." + name + " + 1"
+			}
+		}
+		if o.dbType == dbTypePostgres {
+			// xmax为0的行是新增的
+			var inserted []bool
+			count, err := o.selectBySQL(logPrefix, sql+" RETURNING (xmax = 0)", resultTypeValues, []interface{}{params})(&inserted)
+			if err != nil {
+				return UpsertResult{}, err
+			}
+			result := UpsertResult{Affected: count}
+			for _, isInsert := range inserted {
+				if isInsert {
+					result.Inserted++
+				} else {
+					result.Updated++
+				}
+			}
+			return result, nil
+		}
+		affected, err := o.exec(logPrefix, sql, []interface{}{params})
+		if err != nil {
+			return UpsertResult{}, err
+		}
+		if len(updates) == 0 {
+			return UpsertResult{Affected: affected, Inserted: affected}, nil
+		}
+		return UpsertResult{Affected: affected, Inserted: -1, Updated: -1}, nil
+
+	case dbTypeMssql, dbTypeOracle:
+		on := make([]string, len(conflicts))
+		for i, c := range conflicts {
+			name := o.quoteIdent(c.name)
+			on[i] = "target." + name + " = source." + name
+		}
+		var source string
+		if o.dbType == dbTypeMssql {
+			source = "(VALUES " + values + ") AS source (" + columns + ")"
+		} else {
+			// Oracle不支持VALUES多行，使用SELECT ... FROM dual UNION ALL
+			selects := make([]string, len(rows))
+			for i := range rows {
+				parts := make([]string, len(inserts))
+				for j, c := range inserts {
+					parts[j] = "#{r" + strconv.Itoa(i) + "_" + strconv.Itoa(j) + "} " + o.quoteIdent(c.name)
+				}
+				selects[i] = "SELECT " + strings.Join(parts, ", ") + " FROM dual"
+			}
+			source = "(" + strings.Join(selects, " UNION ALL ") + ") source"
+		}
+		sql = "MERGE INTO " + t.table + " target USING " + source + " ON (" + strings.Join(on, " AND ") + ")"
+		if len(updates) > 0 {
+			sql += " WHEN MATCHED THEN UPDATE SET " + quote(updates, "target.%s = source.%s") + versionSet("target.")
+		}
+		sql += " WHEN NOT MATCHED THEN INSERT (" + columns + ") VALUES (" + quote(inserts, "source.%s") + ")"
+		if o.dbType == dbTypeMssql {
+			var actions []string
+			count, err := o.selectBySQL(logPrefix, sql+" OUTPUT $action;", resultTypeValues, []interface{}{params})(&actions)
+			if err != nil {
+				return UpsertResult{}, err
+			}
+			result := UpsertResult{Affected: count}
+			for _, action := range actions {
+				if action == "INSERT" {
+					result.Inserted++
+				} else {
+					result.Updated++
+				}
+			}
+			return result, nil
+		}
+		affected, err := o.exec(logPrefix, sql, []interface{}{params})
+		if err != nil {
+			return UpsertResult{}, err
+		}
+		return UpsertResult{Affected: affected, Inserted: -1, Updated: -1}, nil
+	}
+	return UpsertResult{}, fmt.Errorf("Upsert error : 不支持的数据库类型")
+}
@@ -0,0 +1,188 @@
+package osm
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testUpsertUser struct {
+	ID       int64    `db:"id,pk,auto"`
+	Email    string   `db:"email"`
+	Nickname string   `db:"nickname"`
+	Tags     []string `db:"tags,json"`
+}
+
+func (testUpsertUser) TableName() string { return "user" }
+
+func TestUpsertMysql(t *testing.T) {
+	t.Run("single row updated", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `user` (`email`, `nickname`, `tags`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `nickname` = VALUES(`nickname`), `tags` = VALUES(`tags`)")).
+			ExpectExec().WithArgs("a@b.c", "a", `["x"]`).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		result, err := o.Upsert("", []string{"email"}, nil, &testUpsertUser{Email: "a@b.c", Nickname: "a", Tags: []string{"x"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != (UpsertResult{Affected: 2, Inserted: 0, Updated: 1}) {
+			t.Errorf("result = %+v", result)
+		}
+	})
+
+	t.Run("batch is chunked", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.options.BatchSize = 2
+		mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `user` (`email`, `nickname`, `tags`) VALUES (?, ?, ?), (?, ?, ?) ON DUPLICATE KEY UPDATE `nickname` = VALUES(`nickname`)")).
+			ExpectExec().WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `user` (`email`, `nickname`, `tags`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `nickname` = VALUES(`nickname`)")).
+			ExpectExec().WithArgs("c", "", nil).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		users := []testUpsertUser{{Email: "a"}, {Email: "b"}, {Email: "c"}}
+		result, err := o.Upsert("", []string{"email"}, []string{"Nickname"}, users)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != (UpsertResult{Affected: 4, Inserted: -1, Updated: -1}) {
+			t.Errorf("result = %+v", result)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("row alias", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.options.MysqlRowAlias = true
+		mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `user` (`email`, `nickname`, `tags`) VALUES (?, ?, ?) AS osm_new ON DUPLICATE KEY UPDATE `nickname` = osm_new.`nickname`")).
+			ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if _, err := o.Upsert("", []string{"email"}, []string{"nickname"}, testUpsertUser{Email: "a"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("tidb uses VALUES", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.dbType = dbTypeTiDB
+		o.options.MysqlRowAlias = true
+		mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `user` (`email`, `nickname`, `tags`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `nickname` = VALUES(`nickname`)")).
+			ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if _, err := o.Upsert("", []string{"email"}, []string{"nickname"}, testUpsertUser{Email: "a"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestUpsertPostgres(t *testing.T) {
+	o, mock := newMockOsm(t)
+	o.dbType = dbTypePostgres
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user" ("email", "nickname", "tags") VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT ("email") DO UPDATE SET "nickname" = EXCLUDED."nickname" RETURNING (xmax = 0)`)).
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true).AddRow(false))
+
+	users := []*testUpsertUser{{Email: "a"}, {Email: "b"}}
+	result, err := o.Upsert("", []string{"email"}, []string{"nickname"}, users)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != (UpsertResult{Affected: 2, Inserted: 1, Updated: 1}) {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestUpsertVersion(t *testing.T) {
+	type doc struct {
+		ID      int64  `db:"id,pk"`
+		Title   string `db:"title"`
+		Version int    `db:"version,version"`
+	}
+	o, mock := newMockOsm(t)
+	o.dbType = dbTypePostgres
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "doc" ("id", "title", "version") VALUES ($1, $2, $3) ON CONFLICT ("id") DO UPDATE SET "title" = EXCLUDED."title", "version" = "doc"."version" + 1 RETURNING (xmax = 0)`)).
+		WithArgs(int64(1), "a", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(false))
+
+	if _, err := o.Upsert("doc", nil, nil, doc{ID: 1, Title: "a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := o.Upsert("doc", nil, []string{"version"}, doc{ID: 1}); err == nil {
+		t.Error("expected error for version column")
+	}
+}
+
+func TestUpsertSqliteDoNothing(t *testing.T) {
+	o, mock := newMockOsm(t)
+	o.dbType = dbTypeSqlite
+	type tag struct {
+		Name string `db:"name,pk"`
+	}
+	mock.ExpectPrepare(regexp.QuoteMeta(`INSERT INTO "tag" ("name") VALUES (?) ON CONFLICT ("name") DO NOTHING`)).
+		ExpectExec().WithArgs("go").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := o.Upsert("tag", nil, nil, tag{Name: "go"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != (UpsertResult{Affected: 1, Inserted: 1}) {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestUpsertMerge(t *testing.T) {
+	t.Run("mssql", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.dbType = dbTypeMssql
+		mock.ExpectQuery(regexp.QuoteMeta(`MERGE INTO [user] target USING (VALUES ($1, $2, $3)) AS source ([email], [nickname], [tags]) ON (target.[email] = source.[email]) WHEN MATCHED THEN UPDATE SET target.[nickname] = source.[nickname], target.[tags] = source.[tags] WHEN NOT MATCHED THEN INSERT ([email], [nickname], [tags]) VALUES (source.[email], source.[nickname], source.[tags]) OUTPUT $action;`)).
+			WillReturnRows(sqlmock.NewRows([]string{"action"}).AddRow("INSERT"))
+
+		result, err := o.Upsert("", []string{"email"}, nil, testUpsertUser{Email: "a"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != (UpsertResult{Affected: 1, Inserted: 1}) {
+			t.Errorf("result = %+v", result)
+		}
+	})
+
+	t.Run("oracle", func(t *testing.T) {
+		o, mock := newMockOsm(t)
+		o.dbType = dbTypeOracle
+		mock.ExpectPrepare(regexp.QuoteMeta(`MERGE INTO "USER" target USING (SELECT :1 "EMAIL", :2 "NICKNAME", :3 "TAGS" FROM dual UNION ALL SELECT :4 "EMAIL", :5 "NICKNAME", :6 "TAGS" FROM dual) source ON (target."EMAIL" = source."EMAIL")`)).
+			ExpectExec().WillReturnResult(sqlmock.NewResult(0, 2))
+
+		result, err := o.Upsert("", []string{"email"}, nil, []testUpsertUser{{Email: "a"}, {Email: "b"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != (UpsertResult{Affected: 2, Inserted: -1, Updated: -1}) {
+			t.Errorf("result = %+v", result)
+		}
+	})
+}
+
+func TestUpsertErrors(t *testing.T) {
+	o, _ := newMockOsm(t)
+	if _, err := o.Upsert("", []string{"missing"}, nil, testUpsertUser{}); err == nil {
+		t.Error("expected error for unknown conflict column")
+	}
+	if _, err := o.Upsert("", []string{"email"}, []string{"email"}, testUpsertUser{}); err == nil {
+		t.Error("expected error for updating conflict column")
+	}
+	if _, err := o.Upsert("t", nil, nil, struct{ Name string }{}); err == nil || !strings.Contains(err.Error(), "pk") {
+		t.Errorf("expected missing conflict columns error, got %v", err)
+	}
+	type deletedOnly struct {
+		ID      int64 `db:"id,pk,readonly"`
+		Deleted bool  `db:"deleted,softdelete"`
+	}
+	if _, err := o.Upsert("t", []string{"deleted"}, nil, deletedOnly{}); err == nil || !strings.Contains(err.Error(), "没有可写入的列") {
+		t.Errorf("expected no writable columns error, got %v", err)
+	}
+	if result, err := o.Upsert("", nil, nil, []testUpsertUser{}); err != nil || result != (UpsertResult{}) {
+		t.Errorf("empty slice: %+v, %v", result, err)
+	}
+}
@@ -2,7 +2,9 @@ package osm
 
 import (
 	"database/sql"
+	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -60,6 +62,11 @@ var commonInitialisms = map[string][]byte{
 
 // camel string, xx_yy to XxYy, 两种,一种为特殊片段
 func toGoNames(name string) (string, string) {
+	return toGoNamesWith(name, commonInitialisms)
+}
+
+// toGoNamesWith 与toGoNames相同，使用指定的缩写词
+func toGoNamesWith(name string, initialisms map[string][]byte) (string, string) {
 	num := len(name)
 	data := make([]byte, num)
 	dataSpecial := make([]byte, num)
@@ -70,7 +77,7 @@ func toGoNames(name string) (string, string) {
 	for i := 0; i < num; i++ {
 		d := name[i]
 		if d == '_' {
-			word, ok := commonInitialisms[string(data[firstPoint:point])]
+			word, ok := initialisms[string(data[firstPoint:point])]
 			if ok {
 				for j, b := range word {
 					dataSpecial[firstPoint+j] = b
@@ -94,7 +101,7 @@ func toGoNames(name string) (string, string) {
 			isFirst = false
 		}
 	}
-	word, ok := commonInitialisms[string(data[firstPoint:point])]
+	word, ok := initialisms[string(data[firstPoint:point])]
 	if ok {
 		for j, b := range word {
 			dataSpecial[firstPoint+j] = b
@@ -103,22 +110,34 @@ func toGoNames(name string) (string, string) {
 	return string(data[:point]), string(dataSpecial[:point])
 }
 
-func findField(tagMap, nameMap map[string]*structFieldInfo, name string) *structFieldInfo {
+// findFieldBy 先按db标签，再按mapper查找列对应的成员，列对应多个同层的同名成员时返回错误
+func findFieldBy(mapper NameMapper, tagMap, nameMap map[string]*structFieldInfo, name string) (*structFieldInfo, error) {
 	v, ok := tagMap[name]
 	if ok {
-		return v
+		return v.checkAmbiguous(name)
 	}
 
-	a, b := toGoNames(name)
-	t, ok := nameMap[a]
-	if ok {
-		return t
+	for _, fieldName := range mapper.ColumnToFields(name) {
+		if t, ok := nameMap[fieldName]; ok {
+			return t.checkAmbiguous(name)
+		}
 	}
-	t, ok = nameMap[b]
-	if ok {
-		return t
+	if folder, ok := mapper.(NameFolder); ok {
+		folded := folder.FoldName(name)
+		var found *structFieldInfo
+		for fieldName, t := range nameMap {
+			if folder.FoldName(fieldName) == folded {
+				if found != nil {
+					return nil, fmt.Errorf("列'%s'对应多个成员'%s'和'%s'", name, found.n, t.n)
+				}
+				found = t
+			}
+		}
+		if found != nil {
+			return found.checkAmbiguous(name)
+		}
 	}
-	return nil
+	return nil, nil
 }
 
 // scanRow 从sql.Rows中读一行数据
@@ -131,20 +150,10 @@ func (o *osmBase) scanRow(
 	lenContainers := len(fields)
 	srcs := make([]*interface{}, lenContainers)
 	refs := make([]interface{}, lenContainers)
-	types := make([]reflect.Type, lenContainers)
-	for i, field := range fields {
+	for i := range fields {
 		ref := new(interface{})
 		refs[i] = ref
 		srcs[i] = ref
-
-		if field == nil {
-			continue
-		}
-		if field.isPtr {
-			types[i] = (*(field.t)).Elem()
-		} else {
-			types[i] = *(field.t)
-		}
 	}
 
 	err := rows.Scan(refs...)
@@ -160,13 +169,25 @@ func (o *osmBase) scanRow(
 		if field == nil {
 			continue
 		}
-		if err := o.convertAssign(logPrefix, values[i], *src, field.isPtr, types[i]); err != nil {
+		if err := o.assignField(logPrefix, field, values[i], *src); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// assignField 将driver返回的值转换后存入field对应的成员dest
+func (o *osmBase) assignField(logPrefix string, field *structFieldInfo, dest reflect.Value, src interface{}) error {
+	destType := *(field.t)
+	if field.isPtr {
+		destType = destType.Elem()
+	}
+	if field.json || isJSONType(destType) {
+		return scanJSON(dest, src, field.isPtr, destType)
+	}
+	return o.convertAssign(logPrefix, dest, src, field.isPtr, destType)
+}
+
 func isNativeParamType(kind reflect.Kind) bool {
 	return kind == reflect.Bool ||
 		kind == reflect.Int ||
@@ -208,28 +229,197 @@ func isValueKind(kind reflect.Kind) bool {
 		kind == reflect.Struct
 }
 
+// structFieldValues 按fields取得struct实例中与各列对应的成员，没有对应成员的列使用一个临时string接收
+func structFieldValues(valueElem reflect.Value, fields []*structFieldInfo) []reflect.Value {
+	values := make([]reflect.Value, len(fields))
+	for i, field := range fields {
+		if field != nil {
+			values[i] = structFieldAlloc(valueElem, field)
+		} else {
+			a := ""
+			values[i] = reflect.ValueOf(&a).Elem()
+		}
+	}
+	return values
+}
+
+// structFieldValue 取得struct实例中field对应的成员，路径上的嵌入struct指针为nil时返回无效的reflect.Value
+func structFieldValue(valueElem reflect.Value, field *structFieldInfo) reflect.Value {
+	v := valueElem
+	for i, x := range field.index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// structFieldAlloc 与structFieldValue相同，路径上为nil的嵌入struct指针会被分配，用于写入成员
+func structFieldAlloc(valueElem reflect.Value, field *structFieldInfo) reflect.Value {
+	v := valueElem
+	for i, x := range field.index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
 type structFieldInfo struct {
-	i int           // index
-	n string        // name
-	t *reflect.Type // type
-	a bool          // anonymous
+	index []int         // 成员的下标，嵌入struct中的成员为逐层的下标
+	n     string        // name
+	t     *reflect.Type // type
+
+	// ambiguous 同一层的多个嵌入struct中有同名的成员，按Go的规则这些成员都不能直接访问
+	ambiguous bool
 
 	isPtr bool
+
+	// 以下来自db标签，如`db:"id,pk,auto"`
+	column     string // 列名，标签中未指定时为空
+	pk         bool   // 主键
+	auto       bool   // 由数据库生成的值，如自增主键，写入时不绑定
+	omitempty  bool   // 零值时不参与写入，用于部分更新
+	hasDefault bool   // default选项，InsertStruct时为零值则不写入，使用数据库的默认值
+	readonly   bool   // 只读列，如计算列，只用于读取
+	json       bool   // 以JSON格式读写
+	version    bool   // 乐观锁的版本号，UpdateStruct时校验并递增
+	softDelete bool   // 软删除的时间，DeleteByPK时写入当前时间，查询时只取为NULL的行
+	createTime bool   // autoCreateTime选项，InsertStruct等写入时为零值则填入当前时间
+	updateTime bool   // autoUpdateTime选项，写入和更新时填入当前时间
+	actor      bool   // autoActor选项，InsertStruct等写入时为零值则填入context中的操作人
+	prefix     string // 嵌套struct或struct切片的列名前缀，prefix=选项
+}
+
+// parseDBTag 解析db标签，格式为逗号分隔的列名和选项，如`db:"name,omitempty,readonly"`，返回列名和选项
+func parseDBTag(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts[0], parts[1:]
 }
 
-func getStructFieldMap(t reflect.Type, tagMap, nameMap map[string]*structFieldInfo, isAnonymous bool) {
+// setTagOptions 根据db标签的选项设置field，返回false表示该成员被`db:"-"`排除，有不支持的选项时返回错误
+func (field *structFieldInfo) setTagOptions(opts []string) (bool, error) {
+	for _, opt := range opts {
+		switch {
+		case opt == "":
+		case opt == "-":
+			return false, nil
+		case opt == "pk":
+			field.pk = true
+		case opt == "auto":
+			field.auto = true
+		case opt == "omitempty":
+			field.omitempty = true
+		case opt == "default":
+			field.hasDefault = true
+		case opt == "readonly":
+			field.readonly = true
+		case opt == "json":
+			field.json = true
+		case opt == "version":
+			field.version = true
+		case opt == "softdelete":
+			field.softDelete = true
+		case opt == "autoCreateTime":
+			field.createTime = true
+		case opt == "autoUpdateTime":
+			field.updateTime = true
+		case opt == "autoActor":
+			field.actor = true
+		case strings.HasPrefix(opt, "prefix="):
+			field.prefix = strings.TrimPrefix(opt, "prefix=")
+		default:
+			return false, fmt.Errorf("成员'%s'的db标签有不支持的选项'%s'", field.n, opt)
+		}
+	}
+	return true, nil
+}
+
+// getStructFieldMap 按db标签和成员名收集struct的成员，嵌入的struct和struct指针会展开，
+// 同名成员按Go的规则浅层的优先，同一层的同名成员标记为ambiguous，db标签有不支持的选项时返回错误
+func getStructFieldMap(t reflect.Type, tagMap, nameMap map[string]*structFieldInfo) error {
+	return collectStructFields(t, nil, map[reflect.Type]bool{}, tagMap, nameMap)
+}
+
+func collectStructFields(t reflect.Type, parent []int, visiting map[reflect.Type]bool, tagMap, nameMap map[string]*structFieldInfo) error {
+	visiting[t] = true
+	defer delete(visiting, t)
 	for i := 0; i < t.NumField(); i++ {
 		t := t.Field(i)
-		if t.Anonymous && t.Type.Kind() == reflect.Struct {
-			getStructFieldMap(t.Type, tagMap, nameMap, true)
+		tag, opts := parseDBTag(t.Tag.Get("db"))
+		if tag == "-" {
 			continue
 		}
+		index := make([]int, len(parent)+1)
+		copy(index, parent)
+		index[len(parent)] = i
+		if t.Anonymous {
+			embedded := t.Type
+			if embedded.Kind() == reflect.Ptr {
+				// 未导出的嵌入指针无法分配
+				if !t.IsExported() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if !visiting[embedded] {
+					if err := collectStructFields(embedded, index, visiting, tagMap, nameMap); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+		}
 
-		info := &structFieldInfo{i: i, n: t.Name, t: &(t.Type), a: isAnonymous, isPtr: t.Type.Kind() == reflect.Ptr}
-		tag := t.Tag.Get("db")
+		// 未导出的成员无法读写
+		if !t.IsExported() {
+			continue
+		}
+		info := &structFieldInfo{index: index, n: t.Name, t: &(t.Type), isPtr: t.Type.Kind() == reflect.Ptr, column: tag}
+		ok, err := info.setTagOptions(opts)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
 		if tag != "" {
-			tagMap[tag] = info
+			addStructField(tagMap, tag, info)
 		}
-		nameMap[t.Name] = info
+		addStructField(nameMap, t.Name, info)
+	}
+	return nil
+}
+
+// addStructField 浅层的成员覆盖深层的同名成员，同一层的同名成员标记为ambiguous
+func addStructField(m map[string]*structFieldInfo, key string, info *structFieldInfo) {
+	old, ok := m[key]
+	switch {
+	case !ok || len(info.index) < len(old.index):
+		m[key] = info
+	case len(info.index) == len(old.index):
+		ambiguous := *old
+		ambiguous.ambiguous = true
+		m[key] = &ambiguous
+	}
+}
+
+// checkAmbiguous 成员为ambiguous时返回错误
+func (field *structFieldInfo) checkAmbiguous(column string) (*structFieldInfo, error) {
+	if field.ambiguous {
+		return nil, fmt.Errorf("列'%s'对应多个嵌入struct中的同名成员'%s'，请使用db标签区分", column, field.n)
 	}
+	return field, nil
 }
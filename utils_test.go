@@ -2,6 +2,7 @@ package osm
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -74,12 +75,12 @@ func TestGetFieldMap(t *testing.T) {
 	tagMap := make(map[string]*structFieldInfo)
 	nameMap := make(map[string]*structFieldInfo)
 
-	getStructFieldMap(b, tagMap, nameMap, false)
+	getStructFieldMap(b, tagMap, nameMap)
 	for k, v := range tagMap {
-		t.Log(k, v.i, v.n, v.t, v.a, v.isPtr)
+		t.Log(k, v.index, v.n, v.t, v.isPtr)
 	}
 	for k, v := range nameMap {
-		t.Log(k, v.i, v.n, v.t, v.a, v.isPtr)
+		t.Log(k, v.index, v.n, v.t, v.isPtr)
 	}
 }
 
@@ -166,31 +167,40 @@ func TestFindField(t *testing.T) {
 	intType := reflect.TypeOf(0)
 
 	tagMap := map[string]*structFieldInfo{
-		"db_name": {i: 0, n: "Name", t: &strType},
-		"db_age":  {i: 1, n: "Age", t: &intType},
+		"db_name": {index: []int{0}, n: "Name", t: &strType},
+		"db_age":  {index: []int{1}, n: "Age", t: &intType},
 	}
 
 	nameMap := map[string]*structFieldInfo{
-		"Name": {i: 0, n: "Name", t: &strType},
-		"Age":  {i: 1, n: "Age", t: &intType},
+		"Name": {index: []int{0}, n: "Name", t: &strType},
+		"Age":  {index: []int{1}, n: "Age", t: &intType},
 	}
 
 	t.Run("tag match", func(t *testing.T) {
-		f := findField(tagMap, nameMap, "db_name")
+		f, err := findFieldBy(SnakeMapper, tagMap, nameMap, "db_name")
+		if err != nil {
+			t.Fatal(err)
+		}
 		if f == nil || f.n != "Name" {
 			t.Errorf("expected Name, got nil")
 		}
 	})
 
 	t.Run("name match", func(t *testing.T) {
-		f := findField(tagMap, nameMap, "Name")
+		f, err := findFieldBy(SnakeMapper, tagMap, nameMap, "Name")
+		if err != nil {
+			t.Fatal(err)
+		}
 		if f == nil || f.n != "Name" {
 			t.Errorf("expected Name, got nil")
 		}
 	})
 
 	t.Run("no match returns nil", func(t *testing.T) {
-		f := findField(tagMap, nameMap, "nonexistent")
+		f, err := findFieldBy(SnakeMapper, tagMap, nameMap, "nonexistent")
+		if err != nil {
+			t.Fatal(err)
+		}
 		if f != nil {
 			t.Errorf("expected nil, got %v", f)
 		}
@@ -212,9 +222,11 @@ func TestTimeFormat(t *testing.T) {
 }
 
 func TestSetDataToParamName(t *testing.T) {
+	o := &osmBase{options: &Options{}}
+
 	t.Run("non-IN scalar", func(t *testing.T) {
 		frag := &sqlFragment{content: "id", isParam: true, isIn: false}
-		setDataToParamName(frag, reflect.ValueOf(42))
+		o.setDataToParamName(frag, reflect.ValueOf(42))
 		if frag.paramValue != 42 {
 			t.Errorf("got %v, want 42", frag.paramValue)
 		}
@@ -222,7 +234,7 @@ func TestSetDataToParamName(t *testing.T) {
 
 	t.Run("IN slice", func(t *testing.T) {
 		frag := &sqlFragment{content: "ids", isParam: true, isIn: true}
-		setDataToParamName(frag, reflect.ValueOf([]int{1, 2, 3}))
+		o.setDataToParamName(frag, reflect.ValueOf([]int{1, 2, 3}))
 		if len(frag.paramValues) != 3 || frag.paramValues[0] != 1 || frag.paramValues[1] != 2 || frag.paramValues[2] != 3 {
 			t.Errorf("got %v", frag.paramValues)
 		}
@@ -230,7 +242,7 @@ func TestSetDataToParamName(t *testing.T) {
 
 	t.Run("IN single value", func(t *testing.T) {
 		frag := &sqlFragment{content: "id", isParam: true, isIn: true}
-		setDataToParamName(frag, reflect.ValueOf(42))
+		o.setDataToParamName(frag, reflect.ValueOf(42))
 		if len(frag.paramValues) != 1 || frag.paramValues[0] != 42 {
 			t.Errorf("got %v", frag.paramValues)
 		}
@@ -239,9 +251,74 @@ func TestSetDataToParamName(t *testing.T) {
 	t.Run("time.Time non-IN", func(t *testing.T) {
 		now := time.Date(2024, 6, 15, 10, 30, 0, 0, time.UTC)
 		frag := &sqlFragment{content: "t", isParam: true, isIn: false}
-		setDataToParamName(frag, reflect.ValueOf(now))
+		o.setDataToParamName(frag, reflect.ValueOf(now))
 		if frag.paramValue.(string) != "2024-06-15 10:30:00" {
 			t.Errorf("got %v, want formatted time", frag.paramValue)
 		}
 	})
 }
+
+func TestParseDBTag(t *testing.T) {
+	name, opts := parseDBTag("id, pk,auto")
+	if name != "id" || len(opts) != 2 || opts[0] != "pk" || opts[1] != "auto" {
+		t.Errorf("got %q %v", name, opts)
+	}
+	name, opts = parseDBTag("")
+	if name != "" || len(opts) != 0 {
+		t.Errorf("got %q %v", name, opts)
+	}
+}
+
+func TestGetStructFieldMapTagOptions(t *testing.T) {
+	type Base struct {
+		Secret string `db:"-"`
+	}
+	type User struct {
+		Base
+		ID       int64  `db:"id,pk,auto"`
+		Name     string `db:"name,omitempty"`
+		FullName string `db:"full_name,readonly"`
+		Settings string `db:"settings,json"`
+		Status   int    `db:"status,default"`
+		Password string `db:"password,-"`
+		Internal string `db:"-"`
+	}
+
+	tagMap := map[string]*structFieldInfo{}
+	nameMap := map[string]*structFieldInfo{}
+	if err := getStructFieldMap(reflect.TypeOf(User{}), tagMap, nameMap); err != nil {
+		t.Fatal(err)
+	}
+
+	if f := tagMap["id"]; f == nil || !f.pk || !f.auto || f.column != "id" {
+		t.Errorf("id: got %+v", f)
+	}
+	if f := tagMap["name"]; f == nil || !f.omitempty || f.pk {
+		t.Errorf("name: got %+v", f)
+	}
+	if f := tagMap["full_name"]; f == nil || !f.readonly {
+		t.Errorf("full_name: got %+v", f)
+	}
+	if f := tagMap["settings"]; f == nil || !f.json {
+		t.Errorf("settings: got %+v", f)
+	}
+	for _, name := range []string{"Secret", "Password", "Internal"} {
+		if _, ok := nameMap[name]; ok {
+			t.Errorf("%s should be excluded", name)
+		}
+	}
+	if f := tagMap["status"]; f == nil || !f.hasDefault {
+		t.Errorf("status: got %+v", f)
+	}
+	if _, ok := tagMap["password"]; ok {
+		t.Error("password should be excluded")
+	}
+
+	type typo struct {
+		Name string `db:"name,omitempy"`
+	}
+	err := getStructFieldMap(reflect.TypeOf(typo{}), map[string]*structFieldInfo{}, map[string]*structFieldInfo{})
+	if err == nil || !strings.Contains(err.Error(), "omitempy") {
+		t.Errorf("expected unknown option error, got %v", err)
+	}
+}